@@ -0,0 +1,114 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/bombe"
+	"github.com/spf13/cobra"
+)
+
+// bombeCmd represents the bombe command
+var bombeCmd = &cobra.Command{
+	Use:   "bombe <ciphertext> <crib>",
+	Short: "Search for the key of a message from a crib, like the Turing-Welchman bombe.",
+	Long: `Search for the key of a message from a crib, like the Turing-Welchman bombe.
+
+The crib is a piece of plaintext expected in the message, placed under the
+ciphertext at --offset. The bombe builds a menu from it and tries every rotor
+order and start position, reporting the stops it cannot rule out along with
+the plugboard connections they imply.
+
+With --checkpoint the stops of the rotor orders run so far are saved to a
+JSON file every --checkpoint-interval and when the run ends, and --resume
+continues a stopped run from there.
+
+	go-enigma-machine bombe "SBOUH ZMHKT CEGTE PAJLO QXN" "WETTERVORHERSAGE" --rotors II,III,V
+	go-enigma-machine bombe "SBOUH ZMHKT CEGTE PAJLO QXN" "WETTERVORHERSAGE" --rotors I,II,III,IV,V,VI,VII,VIII --checkpoint bombe.json --resume`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		offset, err := cmd.Flags().GetInt("offset")
+		cobra.CheckErr(err)
+		rotors, err := cmd.Flags().GetStringSlice("rotors")
+		cobra.CheckErr(err)
+		order, err := cmd.Flags().GetStringSlice("rotor-order")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		ringSettings, err := cmd.Flags().GetString("rotor-ring-settings")
+		cobra.CheckErr(err)
+		interval, err := cmd.Flags().GetDuration("checkpoint-interval")
+		cobra.CheckErr(err)
+		resume := &bombe.Checkpoint{}
+		checkpointFile, resuming, err := readCheckpointFlags(cmd, resume)
+		cobra.CheckErr(err)
+
+		menu, err := bombe.NewMenu(args[0], args[1], offset)
+		cobra.CheckErr(err)
+
+		opts := bombe.Options{
+			Rotors:       rotors,
+			Reflector:    reflector,
+			RingSettings: ringSettings,
+		}
+		if len(order) > 0 {
+			opts.RotorOrders = [][]string{order}
+		}
+		if resuming {
+			opts.Resume = resume
+		}
+		if checkpointFile != "" {
+			opts.Checkpoint = func(c bombe.Checkpoint) {
+				if err := writeCheckpoint(checkpointFile, c); err != nil {
+					fmt.Fprintln(os.Stderr, "Warning: could not save the checkpoint:", err)
+				}
+			}
+			opts.CheckpointInterval = interval
+		}
+
+		fmt.Printf("Menu: %s\n", menu)
+		fmt.Printf("Central letter: %c, closures: %d\n\n", menu.Central, menu.Closures())
+
+		start := time.Now()
+		stops, err := bombe.Run(menu, opts)
+		cobra.CheckErr(err)
+
+		fmt.Printf("%d stops in %s\n", len(stops), time.Since(start).Round(time.Millisecond))
+		for _, stop := range stops {
+			fmt.Println(stop)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bombeCmd)
+
+	bombeCmd.Flags().IntP("offset", "o", 0, "Position of the crib in the ciphertext, starting at 0")
+	bombeCmd.Flags().StringSliceP("rotors", "r", []string{"I", "II", "III", "IV", "V"}, "Rotors to try every order of three of")
+	bombeCmd.Flags().StringSlice("rotor-order", []string{}, "A single rotor order to try instead, left to right")
+	bombeCmd.Flags().StringP("reflector", "u", "B", "Reflector to use")
+	bombeCmd.Flags().StringP("rotor-ring-settings", "s", "", "Ring settings to assume (default A for every rotor)")
+	addCheckpointFlags(bombeCmd)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// addCheckpointFlags adds the flags for saving and resuming a long run.
+func addCheckpointFlags(cmd *cobra.Command) {
+	cmd.Flags().String("checkpoint", "", "File to save the state of the run to, so it can be resumed")
+	cmd.Flags().Duration("checkpoint-interval", time.Minute, "How often to save the --checkpoint file")
+	cmd.Flags().Bool("resume", false, "Continue the run saved in the --checkpoint file")
+}
+
+// readCheckpointFlags returns the checkpoint file, and reads the checkpoint
+// into v when resuming.
+func readCheckpointFlags(cmd *cobra.Command, v any) (string, bool, error) {
+	file, err := cmd.Flags().GetString("checkpoint")
+	if err != nil {
+		return "", false, err
+	}
+	resume, err := cmd.Flags().GetBool("resume")
+	if err != nil {
+		return "", false, err
+	}
+	if !resume {
+		return file, false, nil
+	}
+	if file == "" {
+		return "", false, fmt.Errorf("--resume needs the --checkpoint file to resume from")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return "", false, fmt.Errorf("%s: %w", file, err)
+	}
+	return file, true, nil
+}
+
+// writeCheckpoint saves a checkpoint as JSON. It is written to a temporary
+// file first, so a run stopped while saving leaves the last checkpoint whole.
+func writeCheckpoint(file string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
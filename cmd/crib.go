@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/natac13/go-enigma-machine/pkg/analysis"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/spf13/cobra"
+)
+
+// cribCmd represents the crib command
+var cribCmd = &cobra.Command{
+	Use:   "crib <ciphertext> <crib>",
+	Short: "Find where a crib can sit under a ciphertext.",
+	Long: `Find where a crib can sit under a ciphertext.
+
+The Enigma never encrypts a letter to itself, so the crib is slid along the
+ciphertext and every offset where a crib letter matches the ciphertext letter
+above it is ruled out. The remaining offsets are ranked by how good a bombe
+menu they make, the best one is the --offset to give the bombe command.
+
+	go-enigma-machine crib "SBOUH ZMHKT CEGTE PAJLO QXN" "WETTERVORHERSAGE"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		top, err := cmd.Flags().GetInt("top")
+		cobra.CheckErr(err)
+
+		alignments, eliminated, err := analysis.DragCrib(args[0], args[1])
+		cobra.CheckErr(err)
+
+		fmt.Printf("%d offsets possible, %d ruled out: %v\n\n", len(alignments), len(eliminated), eliminated)
+		if len(alignments) == 0 {
+			return
+		}
+		if top > 0 && top < len(alignments) {
+			alignments = alignments[:top]
+		}
+
+		crib, err := enigma.Normalize(args[1])
+		cobra.CheckErr(err)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "OFFSET\tCLOSURES\tCENTRAL\tCIPHER\tCRIB")
+		for _, a := range alignments {
+			cipher := make([]rune, len(a.Menu.Edges))
+			for i, e := range a.Menu.Edges {
+				cipher[i] = e.Cipher
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%c (%d)\t%s\t%s\n", a.Offset, a.Closures, a.Menu.Central, a.Connections, string(cipher), crib)
+		}
+		tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cribCmd)
+
+	cribCmd.Flags().IntP("top", "n", 10, "Number of alignments to show, 0 for all")
+}
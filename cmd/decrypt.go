@@ -0,0 +1,143 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/spf13/cobra"
+)
+
+// decryptCmd represents the decrypt command
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt a message using the Enigma machine.",
+	Long: `Decrypt a message using the Enigma machine.
+
+The ciphertext may be written in five letter groups, the spacing is ignored.
+The Enigma is reciprocal, so decrypting with the same settings used to
+encrypt gives back the original message. A message encrypted with
+--format preserve is decrypted with --format preserve, which keeps its
+punctuation, spacing and case.
+
+With --procedure=1938 the message starts with the indicator, the message key
+typed twice and encrypted at the rotor positions. The message key is
+recovered from it and the rest is decrypted from the message key.
+
+With --procedure=1940 the message starts with its header, such as
+"1240 - 174 - WXC KCH -", and the message key is decrypted at the start
+position given in it.
+
+With --procedure=naval the message starts with two four letter indicator
+groups, read with the bigram table given with --bigrams. With
+--kenngruppenbuch the trigrams found are checked against it.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		bindMachineFlags(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			cobra.CheckErr(fmt.Errorf("you must provide a message to decrypt"))
+		}
+
+		// get message to decrypt
+		ciphertext := strings.Trim(args[0], " ")
+		if ciphertext == "" {
+			cobra.CheckErr(fmt.Errorf("you must provide a message to decrypt"))
+		}
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+		if format == enigma.FORMAT_PRESERVE {
+			ciphertext = args[0]
+		}
+
+		config, err := readMachineConfig()
+		cobra.CheckErr(err)
+		em, err := config.Build()
+		cobra.CheckErr(err)
+
+		procedure, err := cmd.Flags().GetString("procedure")
+		cobra.CheckErr(err)
+		words, err := cmd.Flags().GetBool("words")
+		cobra.CheckErr(err)
+		if words && format == enigma.FORMAT_PRESERVE {
+			cobra.CheckErr(fmt.Errorf("--words cannot be used with --format preserve"))
+		}
+		if procedure != "" {
+			if format != enigma.FORMAT_LETTERS {
+				cobra.CheckErr(fmt.Errorf("--format %s cannot be used with --procedure", format))
+			}
+			settings := messageSettings{}
+			cobra.CheckErr(readNavalFlags(cmd, &settings))
+			messageKey, decrypted, err := decryptMessage(em, procedure, settings, ciphertext)
+			cobra.CheckErr(err)
+			if words {
+				decrypted = enigma.RecoverWords(decrypted)
+			}
+
+			printMachineConfig(os.Stdout, config)
+
+			fmt.Printf("Encrypted message: %s\n", ciphertext)
+			fmt.Printf("Message key: %s\n", messageKey)
+			fmt.Printf("Decrypted message: %s\n", decrypted)
+			return
+		}
+
+		trace, err := cmd.Flags().GetBool("trace")
+		cobra.CheckErr(err)
+		if trace {
+			if format != enigma.FORMAT_LETTERS {
+				cobra.CheckErr(fmt.Errorf("--format %s cannot be used with --trace", format))
+			}
+			printMachineConfig(os.Stdout, config)
+			result, err := traceMessage(os.Stdout, em, ciphertext)
+			cobra.CheckErr(err)
+			fmt.Printf("\nDecrypted message: %s\n", result)
+			return
+		}
+
+		decrypted, err := em.DecryptFormat(ciphertext, format)
+		cobra.CheckErr(err)
+
+		if words {
+			decrypted = enigma.RecoverWords(decrypted)
+		}
+
+		printMachineConfig(os.Stdout, config)
+
+		fmt.Printf("Encrypted message: %s\n", ciphertext)
+		fmt.Printf("Decrypted message: %s\n", decrypted)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(decryptCmd)
+
+	addMachineFlags(decryptCmd)
+	decryptCmd.Flags().BoolP("words", "w", false, "Split the plaintext into words at the X separators")
+	decryptCmd.Flags().String("procedure", "", "Message key procedure the message was sent with (1938, 1940, naval)")
+	addNavalFlags(decryptCmd)
+	decryptCmd.Flags().BoolP("trace", "t", false, "Print the path of every letter through the machine")
+	decryptCmd.Flags().StringP("format", "f", enigma.FORMAT_LETTERS, "Format of the plaintext (letters, groups, preserve)")
+}
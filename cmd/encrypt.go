@@ -23,21 +23,70 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/natac13/go-enigma-machine/pkg/enigma"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // encryptCmd represents the encrypt command
 var encryptCmd = &cobra.Command{
 	Use:   "encrypt",
 	Short: "Encrypt a message using the Enigma machine.",
-	Long:  `Encrypt a message using the Enigma machine.`,
+	Long: `Encrypt a message using the Enigma machine.
+
+The message can be given as an argument, read from one or more files with
+--in, or piped in on stdin. Each input file is encrypted from the configured
+rotor positions and its ciphertext is written on its own line.
+
+The ciphertext is written in five letter groups. --format letters leaves out
+the spacing, and --format preserve keeps punctuation, spacing and the case of
+the letters, stepping the rotors only for the letters A to Z, so decrypting
+with --format preserve gives back the text exactly.
+
+	cat msg.txt | go-enigma-machine encrypt > out.txt
+	go-enigma-machine encrypt --in a.txt,b.txt --out ciphertexts.txt
+	go-enigma-machine encrypt --format preserve "Hello, world!"`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		bindMachineFlags(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		inputs, err := cmd.Flags().GetStringSlice("in")
+		cobra.CheckErr(err)
+		output, err := cmd.Flags().GetString("out")
+		cobra.CheckErr(err)
+		procedure, err := cmd.Flags().GetString("procedure")
+		cobra.CheckErr(err)
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		if len(args) > 0 && len(inputs) > 0 {
+			cobra.CheckErr(fmt.Errorf("provide either a message or input files, not both"))
+		}
+		if procedure != "" && format != enigma.FORMAT_GROUPS {
+			cobra.CheckErr(fmt.Errorf("--format %s cannot be used with --procedure", format))
+		}
+
+		config, err := readMachineConfig()
+		cobra.CheckErr(err)
+
+		out := os.Stdout
+		if output != "" {
+			out, err = os.Create(output)
+			cobra.CheckErr(err)
+			defer out.Close()
+		}
+
+		// without a message argument, read the plaintext from the input
+		// files or stdin and write only the ciphertext so it can be piped
 		if len(args) < 1 {
-			cobra.CheckErr(fmt.Errorf("you must provide a message to encrypt"))
+			if procedure != "" {
+				cobra.CheckErr(fmt.Errorf("the %s procedure needs the message as an argument", procedure))
+			}
+			cobra.CheckErr(encryptInputs(config, inputs, out, format))
+			return
 		}
 
 		// get message to encrypt
@@ -45,93 +94,116 @@ var encryptCmd = &cobra.Command{
 		if message == "" {
 			cobra.CheckErr(fmt.Errorf("you must provide a message to encrypt"))
 		}
-
-		// get enigma machine settings
-		reflectorSelection := viper.GetString("reflector")
-		if reflectorSelection == "" {
-			cobra.CheckErr(fmt.Errorf("reflector default not set this should not happen"))
+		if format == enigma.FORMAT_PRESERVE {
+			message = args[0]
 		}
 
-		rotorSelection := viper.GetStringSlice("rotors")
-		if len(rotorSelection) == 0 {
-			cobra.CheckErr(fmt.Errorf("rotor default not set this should not happen"))
-		}
+		em, err := config.Build()
+		cobra.CheckErr(err)
 
-		rotorPositions := viper.GetString("rotor-positions")
-		if len(rotorPositions) == 0 {
-			cobra.CheckErr(fmt.Errorf("rotor positions default not set this should not happen"))
-		}
-		if len(rotorSelection) != len(rotorPositions) {
-			cobra.CheckErr(fmt.Errorf("rotor selection and rotor positions must have the same length"))
-		}
+		if procedure != "" {
+			settings := messageSettings{}
+			settings.messageKey, err = cmd.Flags().GetString("message-key")
+			cobra.CheckErr(err)
+			settings.start, err = cmd.Flags().GetString("start")
+			cobra.CheckErr(err)
+			settings.sent, err = cmd.Flags().GetString("time")
+			cobra.CheckErr(err)
+			settings.kenngruppe, err = cmd.Flags().GetString("kenngruppe")
+			cobra.CheckErr(err)
+			cobra.CheckErr(readNavalFlags(cmd, &settings))
+			m, err := encryptMessage(em, procedure, settings, message)
+			cobra.CheckErr(err)
 
-		rotorRingSettings := viper.GetString("rotor-ring-settings")
-		if len(rotorRingSettings) == 0 {
-			cobra.CheckErr(fmt.Errorf("rotor ring settings default not set this should not happen"))
-		}
-		if len(rotorSelection) != len(rotorRingSettings) {
-			cobra.CheckErr(fmt.Errorf("rotor selection and rotor ring settings must have the same length"))
-		}
+			if output != "" {
+				_, err = fmt.Fprintln(out, m)
+				cobra.CheckErr(err)
+				return
+			}
 
-		plugboardPairsSelection := viper.GetStringSlice("plugboard.pairs")
-		if len(plugboardPairsSelection) > 10 {
-			cobra.CheckErr(fmt.Errorf("plugboard pairs must be 10 or fewer"))
+			printMachineConfig(os.Stdout, config)
+
+			fmt.Printf("Original message: %s\n", message)
+			fmt.Printf("%s: %s\n", m.label, m.preamble)
+			fmt.Printf("Encrypted message: %s\n", m)
+			return
 		}
 
-		// create enigma machine
-		plugboard := enigma.NewPlugboard()
-		reflector, err := enigma.CreateReflectorFromSelection(reflectorSelection)
+		trace, err := cmd.Flags().GetBool("trace")
 		cobra.CheckErr(err)
-
-		rotors := make([]*enigma.Rotor, len(rotorSelection))
-		for i, rotorName := range rotorSelection {
-			rotor, err := enigma.CreateRotorFromSelection(rotorName)
+		if trace {
+			if format != enigma.FORMAT_GROUPS {
+				cobra.CheckErr(fmt.Errorf("--format %s cannot be used with --trace", format))
+			}
+			printMachineConfig(os.Stdout, config)
+			result, err := traceMessage(os.Stdout, em, message)
 			cobra.CheckErr(err)
-			rotors[i] = rotor
+			fmt.Printf("\nEncrypted message: %s\n", result)
+			return
 		}
 
-		em := enigma.NewEnigmaMachine(plugboard, rotors, reflector)
-
-		err = em.SetRotorPositions(strings.Split(rotorPositions, ""))
-		cobra.CheckErr(err)
-		err = em.SetRotorRingSettings(strings.Split(rotorRingSettings, ""))
+		encrypted, err := em.EncryptFormat(message, format)
 		cobra.CheckErr(err)
 
-		if len(plugboardPairsSelection) > 0 {
-			for _, pair := range plugboardPairsSelection {
-				if len(pair) != 2 {
-					cobra.CheckErr(fmt.Errorf("plugboard pairs must be two characters long"))
-				}
-				a := rune(pair[0])
-				b := rune(pair[1])
-				em.AddPlugboardConnection(a, b)
-			}
+		if output != "" {
+			_, err = fmt.Fprintln(out, encrypted)
+			cobra.CheckErr(err)
+			return
 		}
 
-		encrypted, err := em.EncryptString(message)
-		cobra.CheckErr(err)
-
-		fmt.Printf(`
-Enigma machine settings used:
-- Reflector: %s
-- Rotors: %s
-- Rotor positions: %s
-- Rotor ring settings: %s
-- Plugboard pairs: %s
-
-`,
-			reflectorSelection,
-			rotorSelection,
-			rotorPositions,
-			rotorRingSettings,
-			plugboardPairsSelection,
-		)
+		printMachineConfig(os.Stdout, config)
 
 		fmt.Printf("Original message: %s\n", message)
 		fmt.Printf("Encrypted message: %s\n", encrypted)
 	},
 }
 
+// encryptInputs encrypts every input file, or stdin when there are none, and
+// writes each ciphertext on its own line. The machine is reset before every
+// file, so each one is encrypted from the configured rotor positions.
+func encryptInputs(config enigma.Config, inputs []string, out io.Writer, format string) error {
+	em, err := config.Build()
+	if err != nil {
+		return err
+	}
+
+	if len(inputs) == 0 {
+		return encryptStream(em, os.Stdin, out, format)
+	}
+
+	for _, input := range inputs {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		em.Reset()
+		err = encryptStream(em, f, out, format)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", input, err)
+		}
+	}
+
+	return nil
+}
+
+// encryptStream encrypts everything read from in. In the preserve format the
+// line breaks of the input are kept, so no line break is added at the end.
+func encryptStream(em *enigma.EnigmaMachine, in io.Reader, out io.Writer, format string) error {
+	w := enigma.NewEncryptingWriter(out, em)
+	if err := w.SetFormat(format); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	if format == enigma.FORMAT_PRESERVE {
+		return nil
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
+
 func init() {
 	rootCmd.AddCommand(encryptCmd)
 	// Here you will define your flags and configuration settings.
@@ -140,21 +212,15 @@ func init() {
 	// encryptCmd.PersistentFlags().String("foo", "", "A help for foo")
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
-	encryptCmd.Flags().StringP("reflector", "u", "", "Reflector to use")
-	encryptCmd.Flags().StringSliceP("rotors", "r", []string{}, "Rotors to use")
-	encryptCmd.Flags().StringP("rotor-positions", "d", "", "Rotor positions to use")
-	encryptCmd.Flags().StringP("rotor-ring-settings", "s", "", "Rotor ring settings to use")
-	encryptCmd.Flags().StringSliceP("plugboard-pairs", "p", []string{}, "Plugboard pairs to use")
-
-	viper.BindPFlag("reflector", encryptCmd.Flags().Lookup("reflector"))
-	viper.BindPFlag("rotors", encryptCmd.Flags().Lookup("rotors"))
-	viper.BindPFlag("rotor-positions", encryptCmd.Flags().Lookup("rotor-positions"))
-	viper.BindPFlag("rotor-ring-settings", encryptCmd.Flags().Lookup("rotor-ring-settings"))
-	viper.BindPFlag("plugboard.pairs", encryptCmd.Flags().Lookup("plugboard-pairs"))
-
-	viper.SetDefault("reflector", "B")
-	viper.SetDefault("rotors", []string{"III", "II", "I"})
-	viper.SetDefault("rotor-positions", "AAA")
-	viper.SetDefault("rotor-ring-settings", "AAA")
-	viper.SetDefault("plugboard.pairs", []string{})
+	addMachineFlags(encryptCmd)
+	encryptCmd.Flags().StringSliceP("in", "i", []string{}, "Files to read plaintext from, each is encrypted from the starting settings")
+	encryptCmd.Flags().StringP("out", "o", "", "File to write the ciphertext to")
+	encryptCmd.Flags().String("procedure", "", "Message key procedure to follow (1938, 1940, naval)")
+	encryptCmd.Flags().String("message-key", "", "Message key to use with --procedure, random by default")
+	encryptCmd.Flags().String("start", "", "Start position to send the message key at with --procedure 1940, random by default")
+	encryptCmd.Flags().String("time", "", "Time to write in the header with --procedure 1940, as HHMM, now by default")
+	encryptCmd.Flags().String("kenngruppe", "", "Kenngruppe of the key net with --procedure naval, random from the Kenngruppenbuch by default")
+	addNavalFlags(encryptCmd)
+	encryptCmd.Flags().BoolP("trace", "t", false, "Print the path of every letter through the machine")
+	encryptCmd.Flags().StringP("format", "f", enigma.FORMAT_GROUPS, "Format of the ciphertext (groups, letters, preserve)")
 }
@@ -0,0 +1,223 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/keysheet"
+	"github.com/spf13/cobra"
+)
+
+// keysheetCmd represents the keysheet command
+var keysheetCmd = &cobra.Command{
+	Use:   "keysheet",
+	Short: "Work with monthly key sheets.",
+	Long: `Work with monthly key sheets.
+
+A key sheet, or Schlüsselblatt, gave every operator of a key net the rotor
+order, ring settings, plugboard pairs and Kenngruppen for each day of the
+month.`,
+}
+
+// keysheetGenerateCmd represents the keysheet generate command
+var keysheetGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a random key sheet for a month.",
+	Long: `Generate a random key sheet for a month.
+
+The settings are drawn with crypto/rand following the historical rules: no
+rotor sits in the same slot two days in a row, and no plugboard pair joins
+letters next to each other in the alphabet. The sheet is printed as a table,
+last day first so used days can be cut off, or as JSON with --format json.
+A sheet in JSON can be read back with keysheet show and with the --sheet
+flag of encrypt and decrypt:
+
+	go-enigma-machine keysheet generate --month 2026-10 --out october.txt
+	go-enigma-machine keysheet generate --month 2026-10 --format json --out october.json
+	go-enigma-machine encrypt "bootdev rocks" --sheet october.json --day 5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, err := cmd.Flags().GetString("name")
+		cobra.CheckErr(err)
+		month, err := cmd.Flags().GetString("month")
+		cobra.CheckErr(err)
+		model, err := cmd.Flags().GetString("model")
+		cobra.CheckErr(err)
+		rotors, err := cmd.Flags().GetStringSlice("rotors")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		plugs, err := cmd.Flags().GetInt("plugs")
+		cobra.CheckErr(err)
+		kenngruppen, err := cmd.Flags().GetInt("kenngruppen")
+		cobra.CheckErr(err)
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+		output, err := cmd.Flags().GetString("out")
+		cobra.CheckErr(err)
+
+		if month == "" {
+			month = time.Now().Format("2006-01")
+		}
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			cobra.CheckErr(fmt.Errorf("month %s must be written as YYYY-MM", month))
+		}
+
+		sheet, err := keysheet.Generate(keysheet.Options{
+			Name:        name,
+			Month:       month,
+			Days:        start.AddDate(0, 1, -1).Day(),
+			Model:       model,
+			Rotors:      rotors,
+			Reflector:   reflector,
+			Plugs:       plugs,
+			NoPlugs:     plugs == 0,
+			Kenngruppen: kenngruppen,
+		})
+		cobra.CheckErr(err)
+
+		out := os.Stdout
+		if output != "" {
+			out, err = os.Create(output)
+			cobra.CheckErr(err)
+			defer out.Close()
+		}
+
+		cobra.CheckErr(writeSheet(out, sheet, format))
+	},
+}
+
+// keysheetShowCmd represents the keysheet show command
+var keysheetShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show a key sheet, or the settings of one of its days.",
+	Long: `Show a key sheet, or the settings of one of its days.
+
+The sheet is read from the JSON written by keysheet generate --format json
+and printed as a table. With --day only that day's settings are written, in
+the format of the config file, so they can be used with --config:
+
+	go-enigma-machine keysheet show --sheet october.json
+	go-enigma-machine keysheet show --sheet october.json --day 5 --format yaml --out day5.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, err := cmd.Flags().GetString("sheet")
+		cobra.CheckErr(err)
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+		day, err := cmd.Flags().GetInt("day")
+		cobra.CheckErr(err)
+		output, err := cmd.Flags().GetString("out")
+		cobra.CheckErr(err)
+
+		if name == "" {
+			cobra.CheckErr(fmt.Errorf("you must provide a key sheet with --sheet"))
+		}
+
+		out := os.Stdout
+		if output != "" {
+			out, err = os.Create(output)
+			cobra.CheckErr(err)
+			defer out.Close()
+		}
+
+		if day == 0 {
+			sheet, err := loadSheet(name)
+			cobra.CheckErr(err)
+			cobra.CheckErr(writeSheet(out, sheet, format))
+			return
+		}
+
+		d, err := readSheetDay(name, day)
+		cobra.CheckErr(err)
+		if format == "text" {
+			format = enigma.CONFIG_FORMAT_JSON
+		}
+		data, err := enigma.MarshalConfig(d.Config, format)
+		cobra.CheckErr(err)
+		_, err = out.Write(data)
+		cobra.CheckErr(err)
+		if format == enigma.CONFIG_FORMAT_JSON {
+			fmt.Fprintln(out)
+		}
+	},
+}
+
+// writeSheet writes a whole sheet as a table or as JSON.
+func writeSheet(w io.Writer, sheet *keysheet.Sheet, format string) error {
+	switch format {
+	case "text":
+		return sheet.WriteText(w)
+	case enigma.CONFIG_FORMAT_JSON:
+		return sheet.WriteJSON(w)
+	default:
+		return fmt.Errorf("invalid format %s, a whole sheet is written as text or json", format)
+	}
+}
+
+// loadSheet reads a key sheet from a JSON file.
+func loadSheet(name string) (*keysheet.Sheet, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return keysheet.LoadSheet(f)
+}
+
+// readSheetDay reads the settings of a day from a key sheet file, today's if
+// day is 0.
+func readSheetDay(name string, day int) (keysheet.Day, error) {
+	sheet, err := loadSheet(name)
+	if err != nil {
+		return keysheet.Day{}, err
+	}
+	if day == 0 {
+		day = time.Now().Day()
+	}
+	return sheet.Day(day)
+}
+
+func init() {
+	rootCmd.AddCommand(keysheetCmd)
+	keysheetCmd.AddCommand(keysheetGenerateCmd)
+
+	keysheetGenerateCmd.Flags().String("name", "", "Name of the key net to print on the sheet")
+	keysheetGenerateCmd.Flags().String("month", "", "Month of the sheet as YYYY-MM, the current month by default")
+	keysheetGenerateCmd.Flags().StringP("model", "m", "", "Machine model, M4 for a four rotor sheet")
+	keysheetGenerateCmd.Flags().StringSliceP("rotors", "r", []string{}, "Rotors to pick from, I to V by default or I to VIII for the M4")
+	keysheetGenerateCmd.Flags().StringP("reflector", "u", "", "Reflector, B by default or B-thin for the M4")
+	keysheetGenerateCmd.Flags().IntP("plugs", "p", 10, "Number of plugboard pairs, 0 for none")
+	keysheetGenerateCmd.Flags().Int("kenngruppen", 4, "Number of Kenngruppen for each day")
+	keysheetGenerateCmd.Flags().StringP("format", "f", "text", "Output format: text or json")
+	keysheetGenerateCmd.Flags().StringP("out", "o", "", "File to write to")
+
+	keysheetCmd.AddCommand(keysheetShowCmd)
+	keysheetShowCmd.Flags().String("sheet", "", "Key sheet in JSON to read")
+	keysheetShowCmd.Flags().Int("day", 0, "Only write the settings of this day, in the format of the config file")
+	keysheetShowCmd.Flags().StringP("format", "f", "text", "Output format: text or json, or json, yaml or toml with --day")
+	keysheetShowCmd.Flags().StringP("out", "o", "", "File to write to")
+}
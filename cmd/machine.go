@@ -0,0 +1,171 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	viper.SetDefault("rotors", []string{"III", "II", "I"})
+	viper.SetDefault("plugboard.pairs", []string{})
+}
+
+// addMachineFlags adds the flags for the machine settings to a command.
+func addMachineFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("model", "m", "", "Machine model to check the settings against (I, M3, M4)")
+	cmd.Flags().StringP("reflector", "u", "", "Reflector to use (A, B, C, D, B-thin, C-thin)")
+	cmd.Flags().StringSlice("reflector-wiring", []string{}, "Pairs plugged into the rewirable reflector D")
+	cmd.Flags().StringSliceP("rotors", "r", []string{}, "Rotors to use (I, II, III, IV, V, VI, VII, VIII, Beta, Gamma)")
+	cmd.Flags().StringP("rotor-positions", "d", "", "Rotor positions to use")
+	cmd.Flags().StringP("rotor-ring-settings", "s", "", "Rotor ring settings to use")
+	cmd.Flags().StringSliceP("plugboard-pairs", "p", []string{}, "Plugboard pairs to use")
+	cmd.Flags().String("sheet", "", "Key sheet in JSON to take the day's settings from, the positions still come from --rotor-positions")
+	cmd.Flags().Int("day", 0, "Day of the month to read from the key sheet, today by default")
+}
+
+// bindMachineFlags binds the machine flags of the command being run to viper.
+// It has to happen when the command runs, since viper binds a key to a single
+// flag and several commands share the same keys.
+func bindMachineFlags(cmd *cobra.Command) {
+	viper.BindPFlag("model", cmd.Flags().Lookup("model"))
+	viper.BindPFlag("reflector", cmd.Flags().Lookup("reflector"))
+	viper.BindPFlag("reflector-wiring", cmd.Flags().Lookup("reflector-wiring"))
+	viper.BindPFlag("rotors", cmd.Flags().Lookup("rotors"))
+	viper.BindPFlag("rotor-positions", cmd.Flags().Lookup("rotor-positions"))
+	viper.BindPFlag("rotor-ring-settings", cmd.Flags().Lookup("rotor-ring-settings"))
+	viper.BindPFlag("plugboard.pairs", cmd.Flags().Lookup("plugboard-pairs"))
+	viper.BindPFlag("sheet", cmd.Flags().Lookup("sheet"))
+	viper.BindPFlag("day", cmd.Flags().Lookup("day"))
+}
+
+// readMachineConfig reads the machine settings from the flags and the config
+// file, or from the day of a key sheet, and checks that a machine can be
+// built from them.
+func readMachineConfig() (enigma.Config, error) {
+	if name := viper.GetString("sheet"); name != "" {
+		d, err := readSheetDay(name, viper.GetInt("day"))
+		if err != nil {
+			return enigma.Config{}, err
+		}
+		c := d.Config
+		c.RotorPositions = viper.GetString("rotor-positions")
+		if c.RotorPositions == "" {
+			c.RotorPositions = strings.Repeat("A", len(c.Rotors))
+		}
+		return c, c.Validate()
+	}
+
+	c := enigma.Config{
+		Model:             viper.GetString("model"),
+		Rotors:            viper.GetStringSlice("rotors"),
+		RotorPositions:    viper.GetString("rotor-positions"),
+		RotorRingSettings: viper.GetString("rotor-ring-settings"),
+		Reflector:         reflectorConfig(),
+		Plugboard: enigma.PlugboardConfig{
+			Pairs: viper.GetStringSlice("plugboard.pairs"),
+		},
+	}
+
+	if len(c.Rotors) == 0 {
+		return c, fmt.Errorf("rotor default not set this should not happen")
+	}
+
+	// positions and ring settings default to A for every rotor, so the
+	// four rotor M4 works without spelling them out
+	if c.RotorPositions == "" {
+		c.RotorPositions = strings.Repeat("A", len(c.Rotors))
+	}
+	if c.RotorRingSettings == "" {
+		c.RotorRingSettings = strings.Repeat("A", len(c.Rotors))
+	}
+
+	return c, c.Validate()
+}
+
+// printMachineConfig writes the settings summary shown with every message.
+func printMachineConfig(w io.Writer, c enigma.Config) {
+	model := c.Model
+	if model == "" {
+		model = "default"
+	}
+
+	fmt.Fprintf(w, `
+Enigma machine settings used:
+- Model: %s
+- Reflector: %s
+- Rotors: %s
+- Rotor positions: %s
+- Rotor ring settings: %s
+- Plugboard pairs: %s
+
+`,
+		model,
+		c.Reflector,
+		c.Rotors,
+		c.RotorPositions,
+		c.RotorRingSettings,
+		c.Plugboard.Pairs,
+	)
+}
+
+// reflectorConfig reads the reflector selection and, for the rewirable
+// reflector D, its wiring. The config file can either name the reflector
+// directly or use the nested form:
+//
+//	reflector:
+//	  name: D
+//	  wiring: [AC, DZ, EW, FM, GL, HX, IU, JK, NT, PQ, RY, SV]
+//
+// The nested form is read by hand since viper does not see keys below a key
+// that is also bound to a flag.
+func reflectorConfig() enigma.ReflectorConfig {
+	r := enigma.ReflectorConfig{
+		Wiring: viper.GetStringSlice("reflector-wiring"),
+	}
+
+	switch reflector := viper.Get("reflector").(type) {
+	case map[string]any:
+		r.Name = cast.ToString(reflector["name"])
+		if len(r.Wiring) == 0 {
+			r.Wiring = cast.ToStringSlice(reflector["wiring"])
+		}
+	default:
+		r.Name = cast.ToString(reflector)
+	}
+
+	if r.Name == "" && len(r.Wiring) > 0 {
+		r.Name = "D"
+	}
+	if r.Name == "" {
+		r.Name = "B"
+	}
+
+	return r
+}
@@ -0,0 +1,183 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/naval"
+	"github.com/spf13/cobra"
+)
+
+// sentMessage is a message encrypted following a procedure, as it was sent:
+// the indicator or header, then the body.
+type sentMessage struct {
+	// label names the preamble, Indicator or Header
+	label    string
+	preamble string
+	body     string
+}
+
+func (m sentMessage) String() string {
+	return fmt.Sprintf("%s %s", m.preamble, m.body)
+}
+
+// messageSettings are the operator's choices for one message, and the
+// tables of the naval procedure. Empty ones are chosen at random, or the
+// current time for sent.
+type messageSettings struct {
+	messageKey string
+	start      string
+	sent       string
+
+	kenngruppe      string
+	bigrams         string
+	kenngruppenbuch string
+}
+
+// addNavalFlags adds the flags for the tables of the naval procedure.
+func addNavalFlags(cmd *cobra.Command) {
+	cmd.Flags().String("bigrams", "", "File with the bigram table of the naval procedure, a swap such as AB CD on each line")
+	cmd.Flags().String("kenngruppenbuch", "", "File with the trigrams of the Kenngruppenbuch for the naval procedure")
+}
+
+// readNavalFlags reads the table files of the naval procedure.
+func readNavalFlags(cmd *cobra.Command, settings *messageSettings) error {
+	var err error
+	if settings.bigrams, err = cmd.Flags().GetString("bigrams"); err != nil {
+		return err
+	}
+	settings.kenngruppenbuch, err = cmd.Flags().GetString("kenngruppenbuch")
+	return err
+}
+
+// navalProcedure loads the tables of the naval procedure around the machine.
+func navalProcedure(em *enigma.EnigmaMachine, settings messageSettings) (*naval.Procedure, error) {
+	if settings.bigrams == "" {
+		return nil, fmt.Errorf("the naval procedure needs a bigram table, given with --bigrams")
+	}
+	bigrams, err := naval.LoadBigramTableFile(settings.bigrams)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &naval.Procedure{Machine: em, Bigrams: bigrams}
+	if settings.kenngruppenbuch != "" {
+		if p.Kenngruppenbuch, err = naval.LoadKenngruppenbuchFile(settings.kenngruppenbuch); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// encryptMessage encrypts a message following a message key procedure. For
+// the 1938 and naval procedures the configured rotor positions are the daily
+// start position.
+func encryptMessage(em *enigma.EnigmaMachine, procedure string, settings messageSettings, message string) (sentMessage, error) {
+	n := len(em.GetRotorPositions())
+	if settings.messageKey == "" {
+		var err error
+		settings.messageKey, err = enigma.RandomMessageKey(n)
+		if err != nil {
+			return sentMessage{}, err
+		}
+	}
+
+	switch procedure {
+	case enigma.PROCEDURE_1938:
+		m, err := em.EncryptWithDoubledKey(settings.messageKey, message)
+		if err != nil {
+			return sentMessage{}, err
+		}
+		return sentMessage{label: "Indicator", preamble: m.Indicator, body: m.Body}, nil
+	case enigma.PROCEDURE_1940:
+		if settings.start == "" {
+			var err error
+			settings.start, err = enigma.RandomMessageKey(n)
+			if err != nil {
+				return sentMessage{}, err
+			}
+		}
+		if settings.sent == "" {
+			settings.sent = time.Now().Format("1504")
+		}
+		if _, err := time.Parse("1504", settings.sent); err != nil {
+			return sentMessage{}, fmt.Errorf("time %s must be HHMM", settings.sent)
+		}
+		h, body, err := em.EncryptWithStartPosition(settings.start, settings.messageKey, message)
+		if err != nil {
+			return sentMessage{}, err
+		}
+		h.Time = settings.sent
+		return sentMessage{label: "Header", preamble: h.String(), body: body}, nil
+	case naval.PROCEDURE_NAVAL:
+		p, err := navalProcedure(em, settings)
+		if err != nil {
+			return sentMessage{}, err
+		}
+		signal, err := p.Encrypt(settings.kenngruppe, message)
+		if err != nil {
+			return sentMessage{}, err
+		}
+		groups := strings.SplitN(signal, " ", 3)
+		return sentMessage{label: "Indicator", preamble: groups[0] + " " + groups[1], body: groups[2]}, nil
+	default:
+		return sentMessage{}, fmt.Errorf("unknown procedure %s", procedure)
+	}
+}
+
+// decryptMessage decrypts a message sent with a message key procedure, the
+// indicator or header followed by the body, and returns the message key and
+// plaintext.
+func decryptMessage(em *enigma.EnigmaMachine, procedure string, settings messageSettings, ciphertext string) (string, string, error) {
+	switch procedure {
+	case enigma.PROCEDURE_1938:
+		letters := strings.Join(strings.Fields(ciphertext), "")
+		n := 2 * len(em.GetRotorPositions())
+		if len(letters) < n {
+			return "", "", fmt.Errorf("the message must start with a %d letter indicator", n)
+		}
+		return em.DecryptWithDoubledKey(enigma.Message{Indicator: letters[:n], Body: letters[n:]})
+	case enigma.PROCEDURE_1940:
+		h, body, err := enigma.ParseMessage(ciphertext)
+		if err != nil {
+			return "", "", err
+		}
+		if letters := len(strings.Join(strings.Fields(body), "")); letters != h.Letters {
+			fmt.Fprintf(os.Stderr, "Warning: the header counts %d letters but the body has %d\n", h.Letters, letters)
+		}
+		return em.DecryptWithStartPosition(h, body)
+	case naval.PROCEDURE_NAVAL:
+		p, err := navalProcedure(em, settings)
+		if err != nil {
+			return "", "", err
+		}
+		_, messageKey, plaintext, err := p.Decrypt(ciphertext)
+		return messageKey, plaintext, err
+	default:
+		return "", "", fmt.Errorf("unknown procedure %s", procedure)
+	}
+}
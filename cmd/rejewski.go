@@ -0,0 +1,169 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/rejewski"
+	"github.com/spf13/cobra"
+)
+
+// rejewskiCmd represents the rejewski command
+var rejewskiCmd = &cobra.Command{
+	Use:   "rejewski [indicators...]",
+	Short: "Find daily keys from doubled message key indicators, like Rejewski.",
+	Long: `Find daily keys from doubled message key indicators, like Rejewski.
+
+Before 1938 every message started with its message key typed twice and
+encrypted at the daily start position. From a day's six letter indicators
+the permutations AD, BE and CF are worked out, and the lengths of their
+cycles, which do not depend on the plugboard, are looked up in a catalogue of
+every rotor order and start position. Around 80 indicators are usually enough.
+
+The indicators can be given as arguments or read from a file with --in, one
+per line. Building the catalogue takes a while, so --catalogue saves it to a
+file the first time and reads it from there afterwards. The catalogue file
+takes the place of a checkpoint: it is the only slow part of the run and is
+built once for all the days, after which a lookup takes no time at all.
+
+	go-enigma-machine rejewski --in indicators.txt --catalogue catalogue.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, err := cmd.Flags().GetString("in")
+		cobra.CheckErr(err)
+		rotors, err := cmd.Flags().GetStringSlice("rotors")
+		cobra.CheckErr(err)
+		order, err := cmd.Flags().GetStringSlice("rotor-order")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		cataloguePath, err := cmd.Flags().GetString("catalogue")
+		cobra.CheckErr(err)
+
+		indicators := args
+		if input != "" {
+			if len(args) > 0 {
+				cobra.CheckErr(fmt.Errorf("provide either indicators or an input file, not both"))
+			}
+			indicators, err = readLines(input)
+			cobra.CheckErr(err)
+		}
+		if len(indicators) == 0 {
+			cobra.CheckErr(fmt.Errorf("you must provide the day's indicators"))
+		}
+
+		perms, err := rejewski.Permutations(indicators)
+		cobra.CheckErr(err)
+		for i, name := range []string{"AD", "BE", "CF"} {
+			fmt.Printf("%s: %s\n", name, perms[i])
+		}
+		characteristic, err := rejewski.CharacteristicOf(perms)
+		cobra.CheckErr(err)
+		fmt.Printf("Characteristic: %s\n\n", characteristic)
+
+		opts := rejewski.Options{Rotors: rotors, Reflector: reflector}
+		if len(order) > 0 {
+			opts.RotorOrders = [][]string{order}
+		}
+		catalogue, err := readCatalogue(cataloguePath, opts)
+		cobra.CheckErr(err)
+
+		entries := catalogue.Lookup(characteristic)
+		fmt.Printf("%d candidate daily keys (rings at A, reflector %s):\n", len(entries), catalogue.Reflector)
+		for _, entry := range entries {
+			fmt.Println(entry)
+		}
+	},
+}
+
+// readCatalogue loads the catalogue from the file, or builds it and saves it
+// there when the file does not exist yet.
+func readCatalogue(path string, opts rejewski.Options) (*rejewski.Catalogue, error) {
+	if path != "" {
+		f, err := os.Open(path)
+		if err == nil {
+			defer f.Close()
+			catalogue, err := rejewski.LoadCatalogue(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return catalogue, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	catalogue, err := rejewski.BuildCatalogue(opts)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Built a catalogue of %d characteristics in %s\n", catalogue.Len(), time.Since(start).Round(time.Millisecond))
+
+	if path == "" {
+		return catalogue, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := catalogue.Save(f); err != nil {
+		return nil, err
+	}
+	return catalogue, nil
+}
+
+// readLines returns the non empty lines of a file.
+func readLines(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func init() {
+	rootCmd.AddCommand(rejewskiCmd)
+
+	rejewskiCmd.Flags().StringP("in", "i", "", "File to read the indicators from, one per line")
+	rejewskiCmd.Flags().StringSliceP("rotors", "r", []string{"I", "II", "III"}, "Rotors to catalogue every order of three of")
+	rejewskiCmd.Flags().StringSlice("rotor-order", []string{}, "A single rotor order to catalogue instead, left to right")
+	rejewskiCmd.Flags().StringP("reflector", "u", "B", "Reflector to use")
+	rejewskiCmd.Flags().StringP("catalogue", "c", "", "File to read the catalogue from, or save it to when it does not exist")
+}
@@ -61,12 +61,23 @@ plugboard:
 		- CD
 		- EF
 		
-The rotors names can be I, II, III, IV, or V. The reflector names can be A, B, or C.
+The rotors names can be I, II, III, IV, V, VI, VII, or VIII. The reflector names can be A, B, C, or D.
+The rewirable reflector D reads its 12 pairs from reflector.wiring:
+
+reflector:
+	name: D
+	wiring: [AC, DZ, EW, FM, GL, HX, IU, JK, NT, PQ, RY, SV]
+
+For the four rotor M4 set model: M4, put the thin rotor Beta or Gamma first and use the
+thin reflector B-thin or C-thin.
 The plugboard pairs can be any two letters from A to Z. Without duplicates.
 
-The machine will use the first rotor in the list as the rightmost rotor, the second rotor
-as the middle rotor, and the third rotor as the leftmost rotor. The reflector and plugboard
-settings will be used as is.
+The config file may also be JSON or TOML, using the same keys.
+
+The rotors are listed left to right, as they stand in the machine: the first rotor in the
+list is the leftmost, next to the reflector, and the last is the rightmost, the fast rotor
+next to the plugboard. The rotor positions and ring settings are given in the same order.
+The reflector and plugboard settings will be used as is.
 
 There are flags to set the rotors, reflector, plugboard pairs, and rotor positions from
 the command line. Run go-enigma-machine --help for more information.
@@ -0,0 +1,207 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+	"github.com/natac13/go-enigma-machine/pkg/search"
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search [ciphertext]",
+	Short: "Sweep rotor orders, ring settings and start positions for the best decrypts.",
+	Long: `Sweep rotor orders, ring settings and start positions for the best decrypts.
+
+The ciphertext is decrypted from every start position of every rotor order
+and ring setting given, with the reflector and plugboard fixed, and each
+decrypt is scored by its index of coincidence or by its n-gram frequencies in
+the --language. Progress is shown on stderr while the sweep runs. It stops
+early after --timeout or on Ctrl-C, and the best settings found so far are
+printed.
+
+With --checkpoint the state of the sweep is saved to a JSON file every
+--checkpoint-interval and when it stops, and --resume continues from there.
+--seed shuffles the order the keyspace is visited in, so a sweep stopped
+early has tried a spread of it. The results do not depend on the seed or on
+being resumed.
+
+	go-enigma-machine search --in intercept.txt --rings AAA,AAN --timeout 1m
+	go-enigma-machine search --in intercept.txt --checkpoint sweep.json --seed 42
+	go-enigma-machine search --in intercept.txt --checkpoint sweep.json --resume`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		input, err := cmd.Flags().GetString("in")
+		cobra.CheckErr(err)
+		rotors, err := cmd.Flags().GetStringSlice("rotors")
+		cobra.CheckErr(err)
+		order, err := cmd.Flags().GetStringSlice("rotor-order")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		pairs, err := cmd.Flags().GetStringSlice("plugboard-pairs")
+		cobra.CheckErr(err)
+		rings, err := cmd.Flags().GetStringSlice("rings")
+		cobra.CheckErr(err)
+		scorerName, err := cmd.Flags().GetString("scorer")
+		cobra.CheckErr(err)
+		languageName, err := cmd.Flags().GetString("language")
+		cobra.CheckErr(err)
+		top, err := cmd.Flags().GetInt("top")
+		cobra.CheckErr(err)
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		cobra.CheckErr(err)
+		workers, err := cmd.Flags().GetInt("workers")
+		cobra.CheckErr(err)
+		seed, err := cmd.Flags().GetInt64("seed")
+		cobra.CheckErr(err)
+		interval, err := cmd.Flags().GetDuration("checkpoint-interval")
+		cobra.CheckErr(err)
+		resume := &search.Checkpoint{}
+		checkpointFile, resuming, err := readCheckpointFlags(cmd, resume)
+		cobra.CheckErr(err)
+
+		if len(args) > 0 && input != "" {
+			cobra.CheckErr(fmt.Errorf("provide either a ciphertext or an input file, not both"))
+		}
+		ciphertext, err := readCiphertext(args, input)
+		cobra.CheckErr(err)
+		// scored and printed from the same letters
+		ciphertext, err = enigma.Normalize(ciphertext)
+		cobra.CheckErr(err)
+
+		scorer, err := readScorer(scorerName, languageName)
+		cobra.CheckErr(err)
+		score, err := search.DecryptScore(ciphertext, scorer)
+		cobra.CheckErr(err)
+
+		opts := search.Options{
+			Machine: enigma.Config{
+				Reflector: enigma.ReflectorConfig{Name: reflector},
+				Plugboard: enigma.PlugboardConfig{Pairs: pairs},
+			},
+			Rotors:       rotors,
+			RingSettings: rings,
+			Score:        score,
+			Top:          top,
+			Workers:      workers,
+			Progress: func(p search.Progress) {
+				fmt.Fprintf(os.Stderr, "\r%5.1f%% %d of %d trials, %s left ",
+					100*p.Fraction(), p.Done, p.Total, p.Remaining.Round(time.Second))
+			},
+		}
+		if len(order) > 0 {
+			opts.RotorOrders = [][]string{order}
+		}
+		opts.Seed = seed
+		if resuming {
+			opts.Resume = resume
+		}
+		if checkpointFile != "" {
+			opts.Checkpoint = func(c search.Checkpoint) {
+				if err := writeCheckpoint(checkpointFile, c); err != nil {
+					fmt.Fprintln(os.Stderr, "Warning: could not save the checkpoint:", err)
+				}
+			}
+			opts.CheckpointInterval = interval
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		start := time.Now()
+		results, err := search.Search(ctx, opts)
+		cobra.CheckErr(err)
+		best := search.Collect(results, top)
+		fmt.Fprintln(os.Stderr)
+
+		if ctx.Err() != nil {
+			fmt.Printf("Stopped after %s, the best settings so far:\n", time.Since(start).Round(time.Millisecond))
+		} else {
+			fmt.Printf("%d results in %s\n", len(best), time.Since(start).Round(time.Millisecond))
+		}
+		for _, r := range best {
+			em, err := r.Config.Build()
+			cobra.CheckErr(err)
+			plaintext, err := em.DecryptString(ciphertext)
+			cobra.CheckErr(err)
+			fmt.Printf("\n%d. %s %s ring %s (score %.4f)\n%s\n",
+				r.Rank,
+				strings.Join(r.Config.Rotors, " "),
+				r.Config.RotorPositions,
+				r.Config.RotorRingSettings,
+				r.Score,
+				plaintext,
+			)
+		}
+	},
+}
+
+// readScorer returns the scorer named, the index of coincidence or the
+// n-gram table of the language.
+func readScorer(name, languageName string) (scoring.Scorer, error) {
+	n := map[string]int{"unigram": 1, "bigram": 2, "trigram": 3, "quadgram": 4}
+	switch {
+	case name == "ioc":
+		return scoring.IoC, nil
+	case n[name] > 0:
+		language, err := scoring.LanguageFromSelection(languageName)
+		if err != nil {
+			return nil, err
+		}
+		return language.NGrams(n[name])
+	default:
+		return nil, fmt.Errorf("unknown scorer: %s", name)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringP("in", "i", "", "File to read the ciphertext from")
+	searchCmd.Flags().StringSliceP("rotors", "r", []string{"I", "II", "III", "IV", "V"}, "Rotors to try every order of three of")
+	searchCmd.Flags().StringSlice("rotor-order", []string{}, "A single rotor order to try instead, left to right")
+	searchCmd.Flags().StringP("reflector", "u", "B", "Reflector to use")
+	searchCmd.Flags().StringSliceP("plugboard-pairs", "p", []string{}, "Plugboard pairs to use for every trial")
+	searchCmd.Flags().StringSlice("rings", []string{}, "Ring settings to try, defaults to A for every rotor")
+	searchCmd.Flags().String("scorer", "ioc", "Score decrypts by ioc, unigram, bigram, trigram or quadgram")
+	searchCmd.Flags().StringP("language", "l", scoring.LANGUAGE_GERMAN, "Language of the plaintext for n-gram scores (german, english)")
+	searchCmd.Flags().IntP("top", "n", 10, "Number of results to show")
+	searchCmd.Flags().Duration("timeout", 0, "Stop the sweep after this long, such as 30s")
+	searchCmd.Flags().IntP("workers", "w", 0, "Number of workers, defaults to the number of CPUs")
+	searchCmd.Flags().Int64("seed", 0, "Shuffle the order the keyspace is visited in with this seed")
+	addCheckpointFlags(searchCmd)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/analysis"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+	"github.com/spf13/cobra"
+)
+
+// solveCmd represents the solve command
+var solveCmd = &cobra.Command{
+	Use:   "solve [ciphertext]",
+	Short: "Search for the key of a message from the ciphertext alone.",
+	Long: `Search for the key of a message from the ciphertext alone.
+
+Every rotor order and start position is tried, keeping the ones whose decrypt
+looks most like language by its index of coincidence. Those are hill-climbed
+on the ring settings and plugboard using the bigram and trigram frequencies
+of the --language, and the best keys are printed with their decrypts. Count
+files given with --ngrams replace the built in tables of the same length,
+with an n-gram and its count on each line.
+
+The ciphertext can be given as an argument, read from a file with --in, or
+piped in on stdin. It works best on messages of a few hundred letters.
+
+With --checkpoint the rotor orders searched and candidates climbed so far are
+saved to a JSON file every --checkpoint-interval and at the end of each
+stage, and --resume continues a stopped run from there with the same
+solutions.
+
+	go-enigma-machine solve --in intercept.txt --top 3
+	go-enigma-machine solve --in intercept.txt --checkpoint solve.json --resume`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		input, err := cmd.Flags().GetString("in")
+		cobra.CheckErr(err)
+		rotors, err := cmd.Flags().GetStringSlice("rotors")
+		cobra.CheckErr(err)
+		order, err := cmd.Flags().GetStringSlice("rotor-order")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		candidates, err := cmd.Flags().GetInt("candidates")
+		cobra.CheckErr(err)
+		rightRings, err := cmd.Flags().GetInt("right-rings")
+		cobra.CheckErr(err)
+		top, err := cmd.Flags().GetInt("top")
+		cobra.CheckErr(err)
+		maxPlugs, err := cmd.Flags().GetInt("max-plugs")
+		cobra.CheckErr(err)
+		languageName, err := cmd.Flags().GetString("language")
+		cobra.CheckErr(err)
+		ngramFiles, err := cmd.Flags().GetStringSlice("ngrams")
+		cobra.CheckErr(err)
+		interval, err := cmd.Flags().GetDuration("checkpoint-interval")
+		cobra.CheckErr(err)
+		resume := &analysis.SolveCheckpoint{}
+		checkpointFile, resuming, err := readCheckpointFlags(cmd, resume)
+		cobra.CheckErr(err)
+
+		if len(args) > 0 && input != "" {
+			cobra.CheckErr(fmt.Errorf("provide either a ciphertext or an input file, not both"))
+		}
+
+		ciphertext, err := readCiphertext(args, input)
+		cobra.CheckErr(err)
+
+		language, err := readLanguage(languageName, ngramFiles)
+		cobra.CheckErr(err)
+
+		opts := analysis.SolveOptions{
+			Rotors:     rotors,
+			Reflector:  reflector,
+			Candidates: candidates,
+			RightRings: rightRings,
+			Top:        top,
+			MaxPlugs:   maxPlugs,
+			Language:   language,
+		}
+		if len(order) > 0 {
+			opts.RotorOrders = [][]string{order}
+		}
+		if resuming {
+			opts.Resume = resume
+		}
+		if checkpointFile != "" {
+			opts.Checkpoint = func(c analysis.SolveCheckpoint) {
+				if err := writeCheckpoint(checkpointFile, c); err != nil {
+					fmt.Fprintln(os.Stderr, "Warning: could not save the checkpoint:", err)
+				}
+			}
+			opts.CheckpointInterval = interval
+		}
+
+		start := time.Now()
+		solutions, err := analysis.Solve(ciphertext, opts)
+		cobra.CheckErr(err)
+
+		fmt.Printf("%d solutions in %s\n", len(solutions), time.Since(start).Round(time.Millisecond))
+		for i, s := range solutions {
+			fmt.Printf("\n%d. %s %s ring %s plugs %s (score %.3f)\n%s\n",
+				i+1,
+				strings.Join(s.Config.Rotors, " "),
+				s.Config.RotorPositions,
+				s.Config.RotorRingSettings,
+				strings.Join(s.Config.Plugboard.Pairs, " "),
+				s.Score,
+				s.Plaintext,
+			)
+		}
+	},
+}
+
+// readCiphertext returns the ciphertext argument, or the contents of the
+// input file or stdin when there is none, with line breaks removed.
+func readCiphertext(args []string, input string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	in := os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(string(data)), ""), nil
+}
+
+// readLanguage returns the built in language with its tables replaced by
+// those in the count files.
+func readLanguage(name string, files []string) (*scoring.Language, error) {
+	language, err := scoring.LanguageFromSelection(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return language, nil
+	}
+
+	tables := []*scoring.NGrams{language.Unigrams, language.Bigrams, language.Trigrams, language.Quadgrams}
+	for _, file := range files {
+		g, err := scoring.LoadNGramsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, g)
+	}
+	return scoring.NewLanguage(language.Name, tables...)
+}
+
+func init() {
+	rootCmd.AddCommand(solveCmd)
+
+	solveCmd.Flags().StringP("in", "i", "", "File to read the ciphertext from")
+	solveCmd.Flags().StringSliceP("rotors", "r", []string{"I", "II", "III", "IV", "V"}, "Rotors to try every order of three of")
+	solveCmd.Flags().StringSlice("rotor-order", []string{}, "A single rotor order to try instead, left to right")
+	solveCmd.Flags().StringP("reflector", "u", "B", "Reflector to use")
+	solveCmd.Flags().IntP("candidates", "c", 100, "Number of start positions to hill-climb")
+	solveCmd.Flags().Int("right-rings", 1, "Number of right rotor ring settings to try with every start position")
+	solveCmd.Flags().IntP("top", "n", 5, "Number of solutions to show")
+	solveCmd.Flags().Int("max-plugs", 10, "Most plugboard pairs to look for")
+	solveCmd.Flags().StringP("language", "l", scoring.LANGUAGE_GERMAN, "Language of the plaintext (german, english)")
+	solveCmd.Flags().StringSlice("ngrams", []string{}, "Count files to score with instead of the built in tables")
+	addCheckpointFlags(solveCmd)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// traceMessage encrypts the message one key press at a time and writes the
+// path of every letter through the machine as a table, one row per letter.
+// Each row gives the positions and ring settings of the rotors, and the
+// letter coming out of every component, as well as the letter going in for
+// the rotors. The message is
+// normalized like it is for EncryptString, so the same messages are accepted.
+func traceMessage(w io.Writer, em *enigma.EnigmaMachine, message string) (string, error) {
+	message, err := enigma.Normalize(message)
+	if err != nil {
+		return "", err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var result strings.Builder
+
+	for _, letter := range message {
+		encrypted, trace, err := em.EncryptTrace(letter)
+		if err != nil {
+			return "", err
+		}
+
+		if result.Len() == 0 {
+			fmt.Fprintln(tw, traceHeader(trace))
+		}
+		result.WriteRune(encrypted)
+
+		row := []string{
+			fmt.Sprint(result.Len()),
+			string(trace.Key),
+			trace.Positions,
+			ringSettings(trace),
+			string(trace.PlugboardIn.Out),
+		}
+		for _, hop := range trace.Forward {
+			row = append(row, rotorCell(hop))
+		}
+		row = append(row, string(trace.Reflector.Out))
+		for _, hop := range trace.Backward {
+			row = append(row, rotorCell(hop))
+		}
+		row = append(row, string(trace.PlugboardOut.Out), string(trace.Lamp))
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return result.String(), tw.Flush()
+}
+
+func traceHeader(trace enigma.Trace) string {
+	header := []string{"#", "KEY", "POSITIONS", "RINGS", "PLUG"}
+	for _, hop := range trace.Forward {
+		header = append(header, rotorColumn(hop))
+	}
+	header = append(header, "UKW "+trace.Reflector.Name)
+	for _, hop := range trace.Backward {
+		header = append(header, rotorColumn(hop))
+	}
+	header = append(header, "PLUG", "LAMP")
+	return strings.Join(header, "\t")
+}
+
+func rotorColumn(hop enigma.RotorHop) string {
+	if hop.Name == "" {
+		return fmt.Sprintf("R%d", hop.Slot+1)
+	}
+	return hop.Name
+}
+
+// rotorCell shows the letter going into a rotor and the letter coming out.
+func rotorCell(hop enigma.RotorHop) string {
+	return fmt.Sprintf("%c>%c", hop.In, hop.Out)
+}
+
+// ringSettings returns the ring settings of the rotors, left to right.
+func ringSettings(trace enigma.Trace) string {
+	rings := make([]rune, len(trace.Forward))
+	for _, hop := range trace.Forward {
+		rings[hop.Slot] = hop.RingSetting
+	}
+	return string(rings)
+}
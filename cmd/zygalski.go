@@ -0,0 +1,196 @@
+/*
+Copyright © 2024 Sean Campbell <sean.campbell13@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/pkg/zygalski"
+	"github.com/spf13/cobra"
+)
+
+// zygalskiCmd represents the zygalski command
+var zygalskiCmd = &cobra.Command{
+	Use:   "zygalski",
+	Short: "Make and stack Zygalski sheets.",
+	Long: `Make and stack Zygalski sheets.
+
+From late 1938 each message sent its own start position in the clear, followed
+by the doubled message key encrypted at it. An indicator that repeats a letter
+three places on, a female, is only possible at about two positions in five.
+Zygalski's sheets mark those positions for each rotor order, and stacking the
+sheets for a day's females leaves only a few ring settings.
+
+	go-enigma-machine zygalski sheets --rotor-order II,I,III --out sheets
+	go-enigma-machine zygalski match --in indicators.txt`,
+}
+
+// zygalskiSheetsCmd represents the zygalski sheets command
+var zygalskiSheetsCmd = &cobra.Command{
+	Use:   "sheets",
+	Short: "Print Zygalski sheets of a rotor order as SVG.",
+	Long: `Print Zygalski sheets of a rotor order as SVG.
+
+A sheet is made for each left rotor position, or only the one given with
+--left. The holes to cut out are filled in. Sheets are written to the --out
+directory as <rotors>-<left>.svg, or to stdout when only one is asked for.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		order, err := cmd.Flags().GetStringSlice("rotor-order")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		left, err := cmd.Flags().GetString("left")
+		cobra.CheckErr(err)
+		out, err := cmd.Flags().GetString("out")
+		cobra.CheckErr(err)
+
+		lefts := []rune{}
+		for r := 'A'; r <= 'Z'; r++ {
+			lefts = append(lefts, r)
+		}
+		if left != "" {
+			if len(left) != 1 {
+				cobra.CheckErr(fmt.Errorf("left rotor position %s must be a single letter", left))
+			}
+			lefts = []rune(strings.ToUpper(left))
+		}
+		if out == "" && len(lefts) > 1 {
+			cobra.CheckErr(fmt.Errorf("you must provide an output directory for more than one sheet"))
+		}
+
+		sheets, err := zygalski.NewSheets(order, reflector)
+		cobra.CheckErr(err)
+		if out == "" {
+			cobra.CheckErr(sheets.WriteSVG(os.Stdout, lefts[0]))
+			return
+		}
+
+		cobra.CheckErr(os.MkdirAll(out, 0o755))
+		for _, l := range lefts {
+			name := filepath.Join(out, fmt.Sprintf("%s-%c.svg", strings.Join(order, "-"), l))
+			f, err := os.Create(name)
+			cobra.CheckErr(err)
+			err = sheets.WriteSVG(f, l)
+			f.Close()
+			cobra.CheckErr(err)
+		}
+		fmt.Printf("Wrote %d sheets to %s, %.1f%% holes\n", len(lefts), out, sheets.Holes()*100)
+	},
+}
+
+// zygalskiMatchCmd represents the zygalski match command
+var zygalskiMatchCmd = &cobra.Command{
+	Use:   "match [indicators...]",
+	Short: "Stack the sheets for a day's indicators.",
+	Long: `Stack the sheets for a day's indicators.
+
+Each indicator is the clear start position and the six encrypted letters,
+such as "RTJ WAHWIK", given as arguments or read from a file with --in, one
+per line. Only the females among them are used; ten or more are usually
+enough to leave a handful of settings over all the rotor orders.
+
+With --checkpoint the settings of the rotor orders matched so far are saved
+to a JSON file every --checkpoint-interval and when the match ends, and
+--resume continues a stopped match from there.
+
+	go-enigma-machine zygalski match --in indicators.txt --rotors I,II,III,IV,V,VI,VII,VIII --checkpoint match.json --resume`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input, err := cmd.Flags().GetString("in")
+		cobra.CheckErr(err)
+		rotors, err := cmd.Flags().GetStringSlice("rotors")
+		cobra.CheckErr(err)
+		order, err := cmd.Flags().GetStringSlice("rotor-order")
+		cobra.CheckErr(err)
+		reflector, err := cmd.Flags().GetString("reflector")
+		cobra.CheckErr(err)
+		interval, err := cmd.Flags().GetDuration("checkpoint-interval")
+		cobra.CheckErr(err)
+		resume := &zygalski.Checkpoint{}
+		checkpointFile, resuming, err := readCheckpointFlags(cmd, resume)
+		cobra.CheckErr(err)
+
+		lines := args
+		if input != "" {
+			if len(args) > 0 {
+				cobra.CheckErr(fmt.Errorf("provide either indicators or an input file, not both"))
+			}
+			lines, err = readLines(input)
+			cobra.CheckErr(err)
+		}
+		if len(lines) == 0 {
+			cobra.CheckErr(fmt.Errorf("you must provide the day's indicators"))
+		}
+
+		indicators := []zygalski.Indicator{}
+		females := 0
+		for _, line := range lines {
+			indicator, err := zygalski.ParseIndicator(line)
+			cobra.CheckErr(err)
+			indicators = append(indicators, indicator)
+			females += len(indicator.Females())
+		}
+		fmt.Printf("%d indicators, %d females\n", len(indicators), females)
+
+		opts := zygalski.Options{Rotors: rotors, Reflector: reflector}
+		if len(order) > 0 {
+			opts.RotorOrders = [][]string{order}
+		}
+		if resuming {
+			opts.Resume = resume
+		}
+		if checkpointFile != "" {
+			opts.Checkpoint = func(c zygalski.Checkpoint) {
+				if err := writeCheckpoint(checkpointFile, c); err != nil {
+					fmt.Fprintln(os.Stderr, "Warning: could not save the checkpoint:", err)
+				}
+			}
+			opts.CheckpointInterval = interval
+		}
+		settings, err := zygalski.Match(indicators, opts)
+		cobra.CheckErr(err)
+
+		fmt.Printf("%d candidate daily keys:\n", len(settings))
+		for _, setting := range settings {
+			fmt.Println(setting)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(zygalskiCmd)
+	zygalskiCmd.AddCommand(zygalskiSheetsCmd)
+	zygalskiCmd.AddCommand(zygalskiMatchCmd)
+
+	zygalskiSheetsCmd.Flags().StringSlice("rotor-order", []string{"I", "II", "III"}, "Rotor order of the sheets, left to right")
+	zygalskiSheetsCmd.Flags().StringP("reflector", "u", "B", "Reflector to use")
+	zygalskiSheetsCmd.Flags().String("left", "", "Only make the sheet for this left rotor position")
+	zygalskiSheetsCmd.Flags().StringP("out", "o", "", "Directory to write the sheets to")
+
+	zygalskiMatchCmd.Flags().StringP("in", "i", "", "File to read the indicators from, one per line")
+	zygalskiMatchCmd.Flags().StringSliceP("rotors", "r", []string{"I", "II", "III", "IV", "V"}, "Rotors to try every order of three of")
+	zygalskiMatchCmd.Flags().StringSlice("rotor-order", []string{}, "A single rotor order to try instead, left to right")
+	zygalskiMatchCmd.Flags().StringP("reflector", "u", "B", "Reflector to use")
+	addCheckpointFlags(zygalskiMatchCmd)
+}
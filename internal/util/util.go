@@ -0,0 +1,82 @@
+// Package util holds the helpers shared by the attacks: the position letters,
+// the size of a keyspace and the worker pool that sweeps it.
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// Alphabet holds the letters by index, so setting the rotors does not
+// allocate a string for every trial.
+var Alphabet = func() []string {
+	l := make([]string, 26)
+	for i := range l {
+		l[i] = string(rune('A' + i))
+	}
+	return l
+}()
+
+// Letters returns the letter of each index.
+func Letters(indices []int) []string {
+	l := make([]string, len(indices))
+	for i, index := range indices {
+		l[i] = Alphabet[index]
+	}
+	return l
+}
+
+// Pow26 returns the number of positions of n rotors.
+func Pow26(n int) int {
+	p := 1
+	for i := 0; i < n; i++ {
+		p *= 26
+	}
+	return p
+}
+
+// Work calls f for each job on the given number of goroutines, and returns
+// once they are done. The jobs not yet started are dropped when ctx is done.
+func Work(ctx context.Context, jobs []int, workers int, f func(job int)) {
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range next {
+				f(job)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		select {
+		case next <- job:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(next)
+	wg.Wait()
+}
+
+// Parallel calls f for every index from 0 to n on the given number of
+// goroutines, and returns the first error in index order.
+func Parallel(n, workers int, f func(i int) error) error {
+	jobs := make([]int, n)
+	for i := range jobs {
+		jobs[i] = i
+	}
+	errs := make([]error, n)
+	Work(context.Background(), jobs, workers, func(i int) { errs[i] = f(i) })
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
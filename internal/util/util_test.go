@@ -0,0 +1,63 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLetters(t *testing.T) {
+	if got := Letters([]int{0, 12, 25}); !reflect.DeepEqual(got, []string{"A", "M", "Z"}) {
+		t.Errorf("Letters = %v", got)
+	}
+}
+
+func TestPow26(t *testing.T) {
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{0, 1},
+		{1, 26},
+		{3, 17576},
+	}
+	for _, tt := range tests {
+		if got := Pow26(tt.n); got != tt.want {
+			t.Errorf("Pow26(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestWork(t *testing.T) {
+	var sum atomic.Int64
+	Work(context.Background(), []int{1, 2, 3, 4}, 3, func(job int) { sum.Add(int64(job)) })
+	if sum.Load() != 10 {
+		t.Errorf("expected every job to run once, got a sum of %d", sum.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ran atomic.Int64
+	Work(ctx, make([]int, 100), 1, func(int) { ran.Add(1) })
+	if ran.Load() > 1 {
+		t.Errorf("expected the jobs to be dropped once cancelled, %d ran", ran.Load())
+	}
+}
+
+func TestParallel(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	err := Parallel(10, 4, func(i int) error {
+		switch i {
+		case 3:
+			return first
+		case 7:
+			return second
+		}
+		return nil
+	})
+	if err != first {
+		t.Errorf("expected the first error in index order, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+)
+
+// SolveCheckpoint is the state of a Solve run, written out so a long run can
+// be stopped and resumed. The solver draws nothing at random, so a resumed
+// run gives the same solutions as one that was never stopped.
+type SolveCheckpoint struct {
+	// Keyspace identifies the ciphertext, options and n-gram tables the run
+	// was started with.
+	Keyspace string `json:"keyspace"`
+	// Searched holds the best start positions of each rotor order searched,
+	// by the index of the order.
+	Searched map[int][]SavedSetting `json:"searched"`
+	// Climbed holds the candidates hill-climbed, by their index among the
+	// best start positions of all the orders.
+	Climbed map[int]SavedSetting `json:"climbed,omitempty"`
+}
+
+// SavedSetting is a setting of the solver in a checkpoint.
+type SavedSetting struct {
+	Rotors       []string `json:"rotors"`
+	Positions    string   `json:"rotor-positions"`
+	RingSettings string   `json:"rotor-ring-settings"`
+	IoC          float64  `json:"ioc"`
+	// Plugboard is the letter each letter is plugged to, and Score the
+	// trigram score, once the setting is climbed.
+	Plugboard string  `json:"plugboard,omitempty"`
+	Score     float64 `json:"score,omitempty"`
+}
+
+// checkpointer records the progress of a run and hands out checkpoints. The
+// workers finish steps concurrently, so it keeps a lock, but the checkpoints
+// are handed out after it is released so saving one does not hold up the
+// workers.
+type checkpointer struct {
+	mu         sync.Mutex
+	checkpoint SolveCheckpoint
+	callback   func(SolveCheckpoint)
+	interval   time.Duration
+	last       time.Time
+	taken      int
+
+	// handing keeps the callback to one call at a time, and the number of
+	// the last checkpoint handed out stops an older one replacing it
+	handing sync.Mutex
+	handed  int
+}
+
+func newCheckpointer(text string, orders [][]string, opts SolveOptions) (*checkpointer, error) {
+	c := &checkpointer{
+		checkpoint: SolveCheckpoint{
+			Keyspace: solveKeyspace(text, orders, opts),
+			Searched: map[int][]SavedSetting{},
+			Climbed:  map[int]SavedSetting{},
+		},
+		callback: opts.Checkpoint,
+		interval: opts.CheckpointInterval,
+		last:     time.Now(),
+	}
+	if r := opts.Resume; r != nil {
+		if r.Keyspace != c.checkpoint.Keyspace {
+			return nil, fmt.Errorf("the checkpoint is for a different ciphertext or options")
+		}
+		maps.Copy(c.checkpoint.Searched, r.Searched)
+		maps.Copy(c.checkpoint.Climbed, r.Climbed)
+	}
+	return c, nil
+}
+
+// searched returns the saved settings of a rotor order, if it was searched.
+func (c *checkpointer) searched(order int) ([]setting, bool, error) {
+	c.mu.Lock()
+	saved, ok := c.checkpoint.Searched[order]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	settings := make([]setting, len(saved))
+	for i, s := range saved {
+		var err error
+		if settings[i], err = s.setting(); err != nil {
+			return nil, false, err
+		}
+	}
+	return settings, true, nil
+}
+
+// climbed returns the saved candidate, if it was climbed.
+func (c *checkpointer) climbed(i int) (SavedSetting, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	saved, ok := c.checkpoint.Climbed[i]
+	return saved, ok
+}
+
+// saveSearched records the settings found for a rotor order.
+func (c *checkpointer) saveSearched(order int, settings []setting) {
+	saved := make([]SavedSetting, len(settings))
+	for i, s := range settings {
+		saved[i] = s.saved()
+	}
+	c.save(func(s *SolveCheckpoint) { s.Searched[order] = saved })
+}
+
+// saveClimbed records a climbed candidate.
+func (c *checkpointer) saveClimbed(i int, candidate *candidate) {
+	saved := candidate.saved()
+	c.save(func(s *SolveCheckpoint) { s.Climbed[i] = saved })
+}
+
+// save records a finished step, and hands out a checkpoint when the interval
+// has passed since the last one.
+func (c *checkpointer) save(update func(*SolveCheckpoint)) {
+	c.mu.Lock()
+	update(&c.checkpoint)
+	if c.callback == nil || time.Since(c.last) < c.interval {
+		c.mu.Unlock()
+		return
+	}
+	c.last = time.Now()
+	n, checkpoint := c.take()
+	c.mu.Unlock()
+
+	c.hand(n, checkpoint)
+}
+
+// flush hands out a checkpoint of everything recorded so far.
+func (c *checkpointer) flush() {
+	if c.callback == nil {
+		return
+	}
+	c.mu.Lock()
+	n, checkpoint := c.take()
+	c.mu.Unlock()
+
+	c.hand(n, checkpoint)
+}
+
+// take copies the checkpoint and numbers the copy. It is called with the lock
+// held.
+func (c *checkpointer) take() (int, SolveCheckpoint) {
+	c.taken++
+	return c.taken, SolveCheckpoint{
+		Keyspace: c.checkpoint.Keyspace,
+		Searched: maps.Clone(c.checkpoint.Searched),
+		Climbed:  maps.Clone(c.checkpoint.Climbed),
+	}
+}
+
+// hand passes a checkpoint to the callback, unless a later one was handed
+// out while it waited.
+func (c *checkpointer) hand(n int, checkpoint SolveCheckpoint) {
+	c.handing.Lock()
+	defer c.handing.Unlock()
+	if n > c.handed {
+		c.handed = n
+		c.callback(checkpoint)
+	}
+}
+
+// solveKeyspace returns a digest of the ciphertext, the options and the
+// n-gram tables that decide the steps of a run.
+func solveKeyspace(text string, orders [][]string, opts SolveOptions) string {
+	data, _ := json.Marshal(struct {
+		Text       string
+		Orders     [][]string
+		Reflector  string
+		Candidates int
+		RightRings int
+		MaxPlugs   int
+		Language   string
+	}{text, orders, opts.Reflector, opts.Candidates, opts.RightRings, opts.MaxPlugs, opts.Language.Digest()})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s setting) saved() SavedSetting {
+	return SavedSetting{
+		Rotors:       s.order,
+		Positions:    strings.Join(util.Letters(s.positions), ""),
+		RingSettings: strings.Join(util.Letters(s.ringSettings), ""),
+		IoC:          s.ioc,
+	}
+}
+
+func (c *candidate) saved() SavedSetting {
+	s := c.setting.saved()
+	plugboard := make([]byte, len(c.plug))
+	for i, p := range c.plug {
+		plugboard[i] = 'A' + p
+	}
+	s.Plugboard = string(plugboard)
+	s.Score = c.score
+	return s
+}
+
+func (s SavedSetting) setting() (setting, error) {
+	positions, err := indices(s.Positions, len(s.Rotors))
+	if err != nil {
+		return setting{}, err
+	}
+	ringSettings, err := indices(s.RingSettings, len(s.Rotors))
+	if err != nil {
+		return setting{}, err
+	}
+	return setting{order: s.Rotors, positions: positions, ringSettings: ringSettings, ioc: s.IoC}, nil
+}
+
+// candidate rebuilds a climbed candidate, working out its scrambler rows
+// again.
+func (s SavedSetting) candidate(cipher []byte, opts SolveOptions) (*candidate, error) {
+	setting, err := s.setting()
+	if err != nil {
+		return nil, err
+	}
+	plug, err := indices(s.Plugboard, 26)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &candidate{setting: setting, score: s.Score}
+	for i, p := range plug {
+		c.plug[i] = byte(p)
+	}
+	em, err := buildScrambler(c.order, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.rows, err = scramblerRows(em, len(cipher), c.positions, c.ringSettings)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// indices reads letters as indices, checking there are n of them.
+func indices(s string, n int) ([]int, error) {
+	if len(s) != n {
+		return nil, fmt.Errorf("invalid checkpoint setting %q: expected %d letters", s, n)
+	}
+	l := make([]int, n)
+	for i := range s {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return nil, fmt.Errorf("invalid checkpoint setting %q", s)
+		}
+		l[i] = int(s[i] - 'A')
+	}
+	return l, nil
+}
@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+)
+
+func TestSolve_Resume(t *testing.T) {
+	em, err := enigma.Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorPositions:    "BLE",
+		RotorRingSettings: "XAN",
+		Reflector:         enigma.ReflectorConfig{Name: "B"},
+		Plugboard:         enigma.PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ"}},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := em.EncryptString(solvePlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SolveOptions{
+		RotorOrders: [][]string{{"II", "IV", "V"}},
+		RightRings:  2,
+		Candidates:  20,
+		Top:         3,
+		Workers:     2,
+	}
+	want, err := Solve(ciphertext, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keep a checkpoint taken part way through climbing, as if the run had
+	// been stopped there
+	var checkpoint []byte
+	stopped := opts
+	stopped.CheckpointInterval = time.Nanosecond
+	stopped.Checkpoint = func(c SolveCheckpoint) {
+		if checkpoint == nil && len(c.Climbed) >= 8 && len(c.Climbed) < opts.Candidates {
+			data, err := json.Marshal(c)
+			if err != nil {
+				t.Error(err)
+			}
+			checkpoint = data
+		}
+	}
+	if _, err := Solve(ciphertext, stopped); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint == nil {
+		t.Fatal("expected a checkpoint part way through climbing")
+	}
+
+	resume := &SolveCheckpoint{}
+	if err := json.Unmarshal(checkpoint, resume); err != nil {
+		t.Fatal(err)
+	}
+	if len(resume.Searched) != 1 || len(resume.Searched[0]) != 20 {
+		t.Fatalf("expected the searched rotor order in the checkpoint, got %v", resume.Searched)
+	}
+
+	resumed := opts
+	resumed.Resume = resume
+	got, err := Solve(ciphertext, resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed solutions = %v, want %v", got, want)
+	}
+
+	other := opts
+	other.MaxPlugs = 6
+	other.Resume = resume
+	if _, err := Solve(ciphertext, other); err == nil {
+		t.Error("expected an error resuming with different options")
+	}
+
+	// tables of the same name but different counts score differently
+	german := scoring.German()
+	trigrams, err := scoring.NewNGrams(map[string]int{"EIN": 2, "DER": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other = opts
+	other.Language, err = scoring.NewLanguage(german.Name, german.Bigrams, trigrams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Resume = resume
+	if _, err := Solve(ciphertext, other); err == nil {
+		t.Error("expected an error resuming with different n-gram tables")
+	}
+}
+
+func TestSolve_CheckpointInterval(t *testing.T) {
+	em, err := enigma.Config{
+		Rotors:         []string{"II", "IV", "V"},
+		RotorPositions: "BLE",
+		Reflector:      enigma.ReflectorConfig{Name: "B"},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := em.EncryptString(solvePlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with an interval longer than the run, only the end of each stage is
+	// saved
+	checkpoints := []SolveCheckpoint{}
+	_, err = Solve(ciphertext, SolveOptions{
+		RotorOrders:        [][]string{{"II", "IV", "V"}, {"IV", "II", "V"}},
+		Candidates:         10,
+		Workers:            2,
+		Checkpoint:         func(c SolveCheckpoint) { checkpoints = append(checkpoints, c) },
+		CheckpointInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 2 {
+		t.Fatalf("expected 2 checkpoints, got %d", len(checkpoints))
+	}
+	if len(checkpoints[0].Searched) != 2 || len(checkpoints[0].Climbed) != 0 {
+		t.Errorf("expected the first checkpoint after searching, got %d searched and %d climbed", len(checkpoints[0].Searched), len(checkpoints[0].Climbed))
+	}
+	if len(checkpoints[1].Climbed) != 10 {
+		t.Errorf("expected the last checkpoint after climbing, got %d climbed", len(checkpoints[1].Climbed))
+	}
+}
@@ -0,0 +1,90 @@
+// Package analysis holds cryptanalysis helpers that work on Enigma
+// ciphertext.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/natac13/go-enigma-machine/pkg/bombe"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// CribAlignment is a place under the ciphertext where the crib could sit.
+type CribAlignment struct {
+	Offset int
+	Menu   *bombe.Menu
+	// Closures is the number of loops in the menu, the more loops the fewer
+	// false stops a bombe run gives.
+	Closures int
+	// Connections is the number of menu edges at the central letter.
+	Connections int
+}
+
+// DragCrib slides the crib along the ciphertext. Since the reflector has no
+// fixed points the Enigma never encrypts a letter to itself, so any offset
+// where a crib letter matches the ciphertext letter above it is impossible.
+// The remaining alignments are ranked by how good a bombe menu they make:
+// most closures first, then the best connected central letter, then the
+// earliest offset. The impossible offsets are returned in order.
+func DragCrib(ciphertext, crib string) ([]CribAlignment, []int, error) {
+	ciphertext, err := enigma.Normalize(ciphertext)
+	if err != nil {
+		return nil, nil, err
+	}
+	crib, err = enigma.Normalize(crib)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(crib) == 0 {
+		return nil, nil, fmt.Errorf("the crib is empty")
+	}
+	if len(crib) > len(ciphertext) {
+		return nil, nil, fmt.Errorf("crib of length %d is longer than the ciphertext of length %d", len(crib), len(ciphertext))
+	}
+
+	alignments := []CribAlignment{}
+	eliminated := []int{}
+	for offset := 0; offset+len(crib) <= len(ciphertext); offset++ {
+		if selfEncrypts(ciphertext[offset:offset+len(crib)], crib) {
+			eliminated = append(eliminated, offset)
+			continue
+		}
+
+		menu, err := bombe.NewMenu(ciphertext, crib, offset)
+		if err != nil {
+			return nil, nil, err
+		}
+		connections := 0
+		for _, e := range menu.Edges {
+			if e.Plain == menu.Central || e.Cipher == menu.Central {
+				connections++
+			}
+		}
+		alignments = append(alignments, CribAlignment{
+			Offset:      offset,
+			Menu:        menu,
+			Closures:    menu.Closures(),
+			Connections: connections,
+		})
+	}
+
+	sort.SliceStable(alignments, func(i, j int) bool {
+		a, b := alignments[i], alignments[j]
+		if a.Closures != b.Closures {
+			return a.Closures > b.Closures
+		}
+		return a.Connections > b.Connections
+	})
+
+	return alignments, eliminated, nil
+}
+
+func selfEncrypts(ciphertext, crib string) bool {
+	for i := range crib {
+		if crib[i] == ciphertext[i] {
+			return true
+		}
+	}
+	return false
+}
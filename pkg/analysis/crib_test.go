@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+func TestDragCrib(t *testing.T) {
+	alignments, eliminated, err := DragCrib("ABCDEF", "XB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// XB under BC, CD, DE and EF is possible, under AB the B matches
+	if !slices.Equal(eliminated, []int{0}) {
+		t.Fatalf("expected offset 0 eliminated, got %v", eliminated)
+	}
+	if len(alignments) != 4 {
+		t.Fatalf("expected 4 alignments, got %d", len(alignments))
+	}
+	for _, a := range alignments {
+		if a.Offset == 0 {
+			t.Fatal("offset 0 should have been eliminated")
+		}
+	}
+}
+
+func TestDragCrib_Ranking(t *testing.T) {
+	// at offset 0 the crib ABC over BCA forms a loop, at offset 3 it does not
+	alignments, _, err := DragCrib("BCAXYZ", "ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alignments[0].Offset != 0 || alignments[0].Closures != 1 {
+		t.Fatalf("expected the loop at offset 0 first, got offset %d with %d closures", alignments[0].Offset, alignments[0].Closures)
+	}
+	for i := 1; i < len(alignments); i++ {
+		if alignments[i].Closures > alignments[i-1].Closures {
+			t.Fatalf("alignments not ranked by closures: %d before %d", alignments[i-1].Closures, alignments[i].Closures)
+		}
+	}
+}
+
+func TestDragCrib_TrueOffsetSurvives(t *testing.T) {
+	em, err := enigma.DefaultConfig().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := "ANXOBERKOMMANDOXWETTERVORHERSAGEXKEINEBESONDERENEREIGNISSE"
+	ciphertext, err := em.EncryptString(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alignments, eliminated, err := DragCrib(ciphertext, "WETTERVORHERSAGE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(eliminated, 16) {
+		t.Fatal("the true offset 16 was eliminated")
+	}
+	if len(eliminated) == 0 {
+		t.Fatal("expected some offsets to be eliminated")
+	}
+	if len(alignments)+len(eliminated) != len(plaintext)-16+1 {
+		t.Fatalf("expected %d offsets in total, got %d", len(plaintext)-16+1, len(alignments)+len(eliminated))
+	}
+}
+
+func TestDragCrib_Invalid(t *testing.T) {
+	tests := []struct {
+		ciphertext string
+		crib       string
+	}{
+		{"ABC", ""},
+		{"ABC", "ABCD"},
+		{"AB1", "X"},
+		{"ABC", "X!"},
+	}
+
+	for _, test := range tests {
+		if _, _, err := DragCrib(test.ciphertext, test.crib); err == nil {
+			t.Errorf("%q %q: expected error, got nil", test.ciphertext, test.crib)
+		}
+	}
+}
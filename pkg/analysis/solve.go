@@ -0,0 +1,484 @@
+package analysis
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+)
+
+// SolveOptions chooses the part of the keyspace the solver searches.
+type SolveOptions struct {
+	// RotorOrders are the rotor orders to try, left to right. When empty,
+	// every order of three of Rotors is tried.
+	RotorOrders [][]string
+	// Rotors to build the orders from, defaults to I to V.
+	Rotors []string
+	// Reflector defaults to B.
+	Reflector string
+	// Candidates is the number of start positions, over all rotor orders,
+	// with the best index of coincidence that are hill-climbed. Defaults to
+	// 100.
+	Candidates int
+	// RightRings is the number of ring settings of the right rotor, spread
+	// evenly around the ring, tried along with every start position. The
+	// search assumes rings at A, which puts the middle rotor turnover in the
+	// wrong place for most of the message when the real ring is far from A.
+	// More ring settings find those keys at the cost of a longer search.
+	// Defaults to 1, ring A only.
+	RightRings int
+	// Language scores the decrypts while climbing the plugboard, defaults to
+	// German. It needs bigram and trigram tables.
+	Language *scoring.Language
+	// Top is the number of solutions returned, defaults to 5.
+	Top int
+	// MaxPlugs is the most plugboard pairs a solution can have, defaults to
+	// 10.
+	MaxPlugs int
+	// Workers is the number of goroutines used, defaults to the number of
+	// CPUs.
+	Workers int
+	// Checkpoint, when set, is called with the state of the run every
+	// CheckpointInterval, as rotor orders are searched and candidates are
+	// climbed, and once more when each of those stages ends.
+	Checkpoint func(SolveCheckpoint)
+	// CheckpointInterval defaults to one minute.
+	CheckpointInterval time.Duration
+	// Resume continues a run from a checkpoint taken with the same
+	// ciphertext and options.
+	Resume *SolveCheckpoint
+}
+
+// Solution is a key found by the solver.
+type Solution struct {
+	Config enigma.Config
+	// Score is the trigram log probability of the plaintext per letter in
+	// the language used, higher is better.
+	Score     float64
+	Plaintext string
+}
+
+// setting is a rotor order and start position, and later ring settings,
+// under consideration.
+type setting struct {
+	order        []string
+	positions    []int
+	ringSettings []int
+	ioc          float64
+}
+
+// Solve attacks a ciphertext without any known plaintext, in the style of
+// Gillogly and of Weierud and Sullivan. The rotor order and start position
+// are searched with the rings at A and no plugboard, keeping the settings
+// whose decrypt has the highest index of coincidence. Each of those is then
+// hill-climbed: the right and middle rings are adjusted, and plugboard pairs
+// are added and swapped while they improve first the index of coincidence,
+// then the bigram score and finally the trigram score of the decrypt in the
+// language. The rings of the best few are tried again with their plugboard.
+// The solutions are returned best first.
+//
+// The search works best on messages of a few hundred letters where the left
+// rotor does not turn over. Short messages have too little statistics to lead
+// the climb, and in long ones the ring settings matter for more of the text.
+func Solve(ciphertext string, opts SolveOptions) ([]Solution, error) {
+	text, err := enigma.Normalize(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(text) == 0 {
+		return nil, fmt.Errorf("the ciphertext is empty")
+	}
+
+	if opts.Reflector == "" {
+		opts.Reflector = "B"
+	}
+	orders := opts.RotorOrders
+	if len(orders) == 0 {
+		rotors := opts.Rotors
+		if len(rotors) == 0 {
+			rotors = []string{"I", "II", "III", "IV", "V"}
+		}
+		orders = enigma.RotorOrders(rotors, 3)
+	}
+	if opts.Candidates <= 0 {
+		opts.Candidates = 100
+	}
+	if opts.RightRings <= 0 {
+		opts.RightRings = 1
+	}
+	opts.RightRings = min(opts.RightRings, 26)
+	if opts.Language == nil {
+		opts.Language = scoring.German()
+	}
+	for _, n := range []int{2, 3} {
+		if _, err := opts.Language.NGrams(n); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Top <= 0 {
+		opts.Top = 5
+	}
+	if opts.MaxPlugs <= 0 || opts.MaxPlugs > 10 {
+		opts.MaxPlugs = 10
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = time.Minute
+	}
+
+	// the ciphertext as letter indices, for the plugboard climb
+	cipher := make([]byte, len(text))
+	for i := range text {
+		cipher[i] = text[i] - 'A'
+	}
+
+	checkpoints, err := newCheckpointer(text, orders, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([][]setting, len(orders))
+	err = util.Parallel(len(orders), opts.Workers, func(i int) error {
+		settings, ok, err := checkpoints.searched(i)
+		if err != nil || ok {
+			found[i] = settings
+			return err
+		}
+		if found[i], err = searchPositions(cipher, orders[i], opts); err != nil {
+			return err
+		}
+		checkpoints.saveSearched(i, found[i])
+		return nil
+	})
+	checkpoints.flush()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []setting{}
+	for _, s := range found {
+		settings = append(settings, s...)
+	}
+	sort.SliceStable(settings, func(i, j int) bool {
+		return settings[i].ioc > settings[j].ioc
+	})
+	settings = settings[:min(len(settings), opts.Candidates)]
+
+	candidates := make([]*candidate, len(settings))
+	err = util.Parallel(len(settings), opts.Workers, func(i int) error {
+		var err error
+		if saved, ok := checkpoints.climbed(i); ok {
+			candidates[i], err = saved.candidate(cipher, opts)
+			return err
+		}
+		if candidates[i], err = climb(text, cipher, settings[i], opts); err != nil {
+			return err
+		}
+		checkpoints.saveClimbed(i, candidates[i])
+		return nil
+	})
+	checkpoints.flush()
+	if err != nil {
+		return nil, err
+	}
+
+	byScore := func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	}
+	sort.SliceStable(candidates, byScore)
+	best := candidates[:min(len(candidates), opts.Top)]
+	err = util.Parallel(len(best), opts.Workers, func(i int) error {
+		return refine(cipher, best[i], opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(candidates, byScore)
+
+	// different settings often climb to the same key, or to an equivalent
+	// one giving the same plaintext
+	results := []Solution{}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		s := c.solution(cipher, opts.Reflector)
+		if seen[s.Plaintext] {
+			continue
+		}
+		seen[s.Plaintext] = true
+		results = append(results, s)
+		if len(results) == opts.Top {
+			break
+		}
+	}
+	return results, nil
+}
+
+// searchPositions decrypts the text from every start position of one rotor
+// order, and every right ring setting asked for, and returns the best
+// settings by index of coincidence.
+func searchPositions(cipher []byte, order []string, opts SolveOptions) ([]setting, error) {
+	right := len(order) - 1
+	plaintext := make([]byte, len(cipher))
+	settings := make([]setting, 0, util.Pow26(len(order))*opts.RightRings)
+	for r := 0; r < opts.RightRings; r++ {
+		ringSettings := make([]int, len(order))
+		ringSettings[right] = r * 26 / opts.RightRings
+
+		// the scrambler is worked out once for the ring setting, so each
+		// start position costs a lookup per letter
+		scrambler, err := enigma.NewScrambler(enigma.Config{
+			Rotors:            order,
+			RotorRingSettings: strings.Join(util.Letters(ringSettings), ""),
+			Reflector:         enigma.ReflectorConfig{Name: opts.Reflector},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for index := 0; index < scrambler.Positions(); index++ {
+			position := index
+			for i, c := range cipher {
+				position = scrambler.Step(position)
+				plaintext[i] = 'A' + scrambler.Permutation(position)[c]
+			}
+
+			positions := make([]int, len(order))
+			for i, n := right, index; i >= 0; i, n = i-1, n/26 {
+				positions[i] = n % 26
+			}
+			settings = append(settings, setting{
+				order:        order,
+				positions:    positions,
+				ringSettings: append([]int(nil), ringSettings...),
+				ioc:          scoring.IndexOfCoincidence(plaintext),
+			})
+		}
+	}
+
+	sort.SliceStable(settings, func(i, j int) bool {
+		return settings[i].ioc > settings[j].ioc
+	})
+	return settings[:min(len(settings), opts.Candidates)], nil
+}
+
+// candidate is a setting being hill-climbed, with its plugboard and the
+// scrambler permutations at each letter of the message.
+type candidate struct {
+	setting
+	plug  [26]byte
+	rows  [][26]byte
+	score float64
+}
+
+// climb hill-climbs the ring settings and plugboard of one setting.
+func climb(text string, cipher []byte, s setting, opts SolveOptions) (*candidate, error) {
+	em, err := buildScrambler(s.order, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for slot := len(s.order) - 1; slot >= max(0, len(s.order)-2); slot-- {
+		best, bestRing := -1.0, s.ringSettings[slot]
+		for ring := 0; ring < 26; ring++ {
+			try := s.withRing(slot, ring)
+			plaintext, err := decrypt(em, text, try.positions, try.ringSettings)
+			if err != nil {
+				return nil, err
+			}
+			if score := scoring.IndexOfCoincidence([]byte(plaintext)); score > best {
+				best, bestRing = score, ring
+			}
+		}
+		s = s.withRing(slot, bestRing)
+	}
+
+	c := &candidate{setting: s}
+	for i := range c.plug {
+		c.plug[i] = byte(i)
+	}
+	c.rows, err = scramblerRows(em, len(cipher), s.positions, s.ringSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	climbPlugboard(cipher, c.rows, &c.plug, opts.MaxPlugs, scoring.IoC)
+	climbPlugboard(cipher, c.rows, &c.plug, opts.MaxPlugs, opts.Language.Bigrams)
+	c.score = climbPlugboard(cipher, c.rows, &c.plug, opts.MaxPlugs, opts.Language.Trigrams)
+	return c, nil
+}
+
+// refine tries the ring settings of the right and middle rotors again now
+// that the plugboard is mostly right, since the index of coincidence alone
+// often leaves the turnover a few letters off. The plugboard is then climbed
+// once more.
+func refine(cipher []byte, c *candidate, opts SolveOptions) error {
+	em, err := buildScrambler(c.order, opts)
+	if err != nil {
+		return err
+	}
+
+	trigrams := opts.Language.Trigrams
+	plaintext := make([]byte, len(cipher))
+	for slot := len(c.order) - 1; slot >= max(0, len(c.order)-2); slot-- {
+		for ring := 0; ring < 26; ring++ {
+			try := c.withRing(slot, ring)
+			rows, err := scramblerRows(em, len(cipher), try.positions, try.ringSettings)
+			if err != nil {
+				return err
+			}
+			decryptPlugged(plaintext, cipher, rows, &c.plug)
+			if score := trigrams.Score(plaintext); score > c.score {
+				c.setting, c.rows, c.score = try, rows, score
+			}
+		}
+	}
+
+	c.score = climbPlugboard(cipher, c.rows, &c.plug, opts.MaxPlugs, trigrams)
+	return nil
+}
+
+// withRing returns the setting with the ring of one rotor changed. The rotor
+// position moves with the ring, which keeps the wiring in the same place and
+// only moves the turnover.
+func (s setting) withRing(slot, ring int) setting {
+	positions := append([]int(nil), s.positions...)
+	ringSettings := append([]int(nil), s.ringSettings...)
+	positions[slot] = (positions[slot] + ring - ringSettings[slot] + 26) % 26
+	ringSettings[slot] = ring
+	return setting{order: s.order, positions: positions, ringSettings: ringSettings, ioc: s.ioc}
+}
+
+func (c *candidate) solution(cipher []byte, reflector string) Solution {
+	plaintext := make([]byte, len(cipher))
+	decryptPlugged(plaintext, cipher, c.rows, &c.plug)
+
+	pairs := []string{}
+	for a, b := range c.plug {
+		if a < int(b) {
+			pairs = append(pairs, string([]rune{rune('A' + a), rune('A' + b)}))
+		}
+	}
+
+	return Solution{
+		Config: enigma.Config{
+			Rotors:            c.order,
+			RotorPositions:    strings.Join(util.Letters(c.positions), ""),
+			RotorRingSettings: strings.Join(util.Letters(c.ringSettings), ""),
+			Reflector:         enigma.ReflectorConfig{Name: reflector},
+			Plugboard:         enigma.PlugboardConfig{Pairs: pairs},
+		},
+		Score:     c.score / float64(len(cipher)),
+		Plaintext: string(plaintext),
+	}
+}
+
+// buildScrambler builds a machine for the rotor order without a plugboard.
+func buildScrambler(order []string, opts SolveOptions) (*enigma.EnigmaMachine, error) {
+	return enigma.Config{
+		Rotors:    order,
+		Reflector: enigma.ReflectorConfig{Name: opts.Reflector},
+	}.Build()
+}
+
+// climbPlugboard changes the plugboard one pair at a time while that improves
+// the score of the decrypt, and returns the final score. Connecting two
+// letters first disconnects them from their old partners, and connecting two
+// letters already plugged together takes the plug out.
+func climbPlugboard(cipher []byte, rows [][26]byte, plug *[26]byte, maxPlugs int, scorer scoring.Scorer) float64 {
+	plaintext := make([]byte, len(cipher))
+	decryptPlugged(plaintext, cipher, rows, plug)
+	best := scorer.Score(plaintext)
+
+	for improved := true; improved; {
+		improved = false
+		for a := 0; a < 26; a++ {
+			for b := a + 1; b < 26; b++ {
+				for _, try := range plugMoves(*plug, a, b) {
+					if plugCount(&try) > maxPlugs {
+						continue
+					}
+					decryptPlugged(plaintext, cipher, rows, &try)
+					if s := scorer.Score(plaintext); s > best {
+						best, *plug, improved = s, try, true
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// plugMoves returns the plugboards to try next for a pair of letters.
+func plugMoves(plug [26]byte, a, b int) [][26]byte {
+	if int(plug[a]) == b {
+		plug[a], plug[b] = byte(a), byte(b)
+		return [][26]byte{plug}
+	}
+
+	oldA, oldB := int(plug[a]), int(plug[b])
+	plug[oldA], plug[oldB] = byte(oldA), byte(oldB)
+	plug[a], plug[b] = byte(b), byte(a)
+	moves := [][26]byte{plug}
+
+	// the letters left behind can also be connected to each other
+	if oldA != a && oldB != b {
+		plug[oldA], plug[oldB] = byte(oldB), byte(oldA)
+		moves = append(moves, plug)
+	}
+	return moves
+}
+
+func plugCount(plug *[26]byte) int {
+	count := 0
+	for a, b := range plug {
+		if a < int(b) {
+			count++
+		}
+	}
+	return count
+}
+
+// decryptPlugged decrypts the cipher, as letter indices, through the
+// plugboard and the scrambler rows into plaintext letters.
+func decryptPlugged(plaintext, cipher []byte, rows [][26]byte, plug *[26]byte) {
+	for i, c := range cipher {
+		plaintext[i] = 'A' + plug[rows[i][plug[c]]]
+	}
+}
+
+// scramblerRows returns the scrambler permutation, without the plugboard, at
+// each of the first length key presses.
+func scramblerRows(em *enigma.EnigmaMachine, length int, positions, ringSettings []int) ([][26]byte, error) {
+	rows := make([][26]byte, length)
+	for x := 0; x < 26; x++ {
+		// pressing the same key over and over gives that letter's entry in
+		// the permutation at every position
+		out, err := decrypt(em, strings.Repeat(string(rune('A'+x)), length), positions, ringSettings)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			rows[i][x] = out[i] - 'A'
+		}
+	}
+	return rows, nil
+}
+
+// decrypt decrypts the text from the given start positions and ring
+// settings.
+func decrypt(em *enigma.EnigmaMachine, text string, positions, ringSettings []int) (string, error) {
+	if err := em.SetRotorRingSettings(util.Letters(ringSettings)); err != nil {
+		return "", err
+	}
+	if err := em.SetRotorPositions(util.Letters(positions)); err != nil {
+		return "", err
+	}
+	return em.DecryptString(text)
+}
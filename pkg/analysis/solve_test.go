@@ -0,0 +1,122 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+)
+
+const solvePlaintext = "DIEVORHUTDESREGIMENTSHATHEUTEMORGENDENFLUSSERREICHTUNDDIEBRUECKEUNBESCHAEDIGTINBESITZGENOMMENXDERFEINDHATSICHINDIEWAELDERNOERDLICHDERSTADTZURUECKGEZOGENXWEITEREAUFKLAERUNGISTIMGANGEXDIETRUPPEISTMUEDEABERINGUTERVERFASSUNGXNACHSCHUBANMUNITIONUNDVERPFLEGUNGWIRDDRINGENDBENOETIGTXERBITTEBEFEHLFUERDENWEITERENVORMARSCHNACHOSTENXDIEFUNKVERBINDUNGZUMNACHBARBATAILLONISTSEITGESTERNABENDUNTERBROCHENXMELDERWURDENENTSANDTX"
+
+func TestSolve(t *testing.T) {
+	key := enigma.Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorPositions:    "BLE",
+		RotorRingSettings: "XAN",
+		Reflector:         enigma.ReflectorConfig{Name: "B"},
+		Plugboard:         enigma.PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ"}},
+	}
+	em, err := key.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := em.EncryptString(solvePlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// searching a single rotor order keeps the test quick, ring N is the
+	// second right ring setting tried
+	solutions, err := Solve(ciphertext, SolveOptions{
+		RotorOrders: [][]string{{"II", "IV", "V"}},
+		RightRings:  2,
+		Top:         3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solutions) == 0 {
+		t.Fatal("expected solutions, got none")
+	}
+
+	best := solutions[0]
+	if best.Plaintext != solvePlaintext {
+		t.Fatalf("expected the plaintext to be recovered, got %s", best.Plaintext)
+	}
+	for i := 1; i < len(solutions); i++ {
+		if solutions[i].Score > solutions[i-1].Score {
+			t.Fatalf("solutions not ordered by score: %f before %f", solutions[i-1].Score, solutions[i].Score)
+		}
+	}
+
+	// the solution is a key that decrypts the message on the machine
+	em, err = best.Config.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := em.DecryptString(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != solvePlaintext {
+		t.Fatalf("the solution key decrypts to %s", plaintext)
+	}
+}
+
+func TestSolve_Invalid(t *testing.T) {
+	tests := []struct {
+		ciphertext string
+		opts       SolveOptions
+	}{
+		{"", SolveOptions{}},
+		{"ABC1", SolveOptions{}},
+		{"ABCDE", SolveOptions{RotorOrders: [][]string{{"I", "II", "IX"}}}},
+		{"ABCDE", SolveOptions{RotorOrders: [][]string{{"I", "II", "III"}}, Reflector: "E"}},
+		{"ABCDE", SolveOptions{Language: &scoring.Language{Name: "empty"}}},
+	}
+
+	for _, test := range tests {
+		if _, err := Solve(test.ciphertext, test.opts); err == nil {
+			t.Errorf("%q: expected error, got nil", test.ciphertext)
+		}
+	}
+}
+
+func TestPlugMoves(t *testing.T) {
+	identity := [26]byte{}
+	for i := range identity {
+		identity[i] = byte(i)
+	}
+
+	// plugging two free letters
+	moves := plugMoves(identity, 0, 1)
+	if len(moves) != 1 || moves[0][0] != 1 || moves[0][1] != 0 {
+		t.Fatalf("expected A-B plugged, got %v", moves)
+	}
+
+	// plugging them again takes the plug out
+	moves = plugMoves(moves[0], 0, 1)
+	if len(moves) != 1 || moves[0] != identity {
+		t.Fatalf("expected A-B unplugged, got %v", moves)
+	}
+
+	// with A-C and B-D plugged, A-B leaves C and D free or plugged together
+	plug := identity
+	plug[0], plug[2], plug[1], plug[3] = 2, 0, 3, 1
+	moves = plugMoves(plug, 0, 1)
+	if len(moves) != 2 {
+		t.Fatalf("expected 2 moves, got %d", len(moves))
+	}
+	if moves[0][0] != 1 || moves[0][2] != 2 || moves[0][3] != 3 {
+		t.Fatalf("expected A-B plugged with C and D free, got %v", moves[0])
+	}
+	if moves[1][0] != 1 || moves[1][2] != 3 || moves[1][3] != 2 {
+		t.Fatalf("expected A-B and C-D plugged, got %v", moves[1])
+	}
+	for _, move := range moves {
+		if plugCount(&move) > 2 {
+			t.Fatalf("expected at most 2 plugs, got %d", plugCount(&move))
+		}
+	}
+}
@@ -0,0 +1,344 @@
+// Package bombe simulates the Turing-Welchman bombe used at Bletchley Park to
+// find Enigma keys from a crib, a piece of known plaintext.
+//
+// For every rotor order and start position the bombe assumes a plugboard
+// partner for the central letter of the menu and follows the consequences
+// through the scramblers on the menu. The diagonal board adds the symmetry
+// of the plugboard: if A is plugged to B then B is plugged to A. A setting
+// where some partner for the central letter does not lead to a contradiction
+// is a stop, which is then checked by hand (or on a machine).
+package bombe
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// Options chooses the part of the keyspace the bombe runs through.
+type Options struct {
+	// RotorOrders are the rotor orders to try, left to right. When empty,
+	// every order of three of Rotors is tried.
+	RotorOrders [][]string
+	// Rotors to build the orders from, defaults to I to V.
+	Rotors []string
+	// Reflector defaults to B.
+	Reflector string
+	// RingSettings defaults to A for every rotor. The rotors step as in the
+	// machine, turnovers included, so the stops are exact for these rings.
+	// With other rings the right setting still stops the bombe, shifted by
+	// the rings, unless they move a turnover into or out of the crib.
+	RingSettings string
+	// Workers is the number of rotor orders run at once, defaults to the
+	// number of CPUs.
+	Workers int
+	// Checkpoint, when set, is called with the rotor orders run so far every
+	// CheckpointInterval, and once more when the run ends.
+	Checkpoint func(Checkpoint)
+	// CheckpointInterval defaults to one minute.
+	CheckpointInterval time.Duration
+	// Resume continues a run from a checkpoint taken with the same menu and
+	// options, skipping the rotor orders it has the stops of.
+	Resume *Checkpoint
+}
+
+// Stop is a setting the bombe could not rule out.
+type Stop struct {
+	Rotors       []string
+	Reflector    string
+	RingSettings string
+	// Position is the rotor start position of the message, before the
+	// first key press.
+	Position string
+	// Steckers are the plugboard connections implied for the letters on the
+	// part of the menu joined to the central letter. A letter connected to
+	// itself is not plugged.
+	Steckers map[rune]rune
+}
+
+// Pairs returns the implied plugboard pairs, such as "AV", in alphabetical
+// order. Unplugged letters are left out.
+func (s Stop) Pairs() []string {
+	pairs := []string{}
+	for a, b := range s.Steckers {
+		if a < b {
+			pairs = append(pairs, string([]rune{a, b}))
+		}
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// Unplugged returns the menu letters the stop implies are not plugged.
+func (s Stop) Unplugged() []rune {
+	letters := []rune{}
+	for a, b := range s.Steckers {
+		if a == b {
+			letters = append(letters, a)
+		}
+	}
+	slices.Sort(letters)
+	return letters
+}
+
+func (s Stop) String() string {
+	return fmt.Sprintf("%s %s ring %s: %s unplugged %s",
+		strings.Join(s.Rotors, " "), s.Position, s.RingSettings,
+		strings.Join(s.Pairs(), " "), string(s.Unplugged()))
+}
+
+// Run runs the bombe over every rotor order and start position and returns
+// the stops, ordered by rotor order and then position.
+func Run(menu *Menu, opts Options) ([]Stop, error) {
+	if len(menu.Edges) == 0 {
+		return nil, fmt.Errorf("the menu is empty")
+	}
+
+	if opts.Reflector == "" {
+		opts.Reflector = "B"
+	}
+	orders := opts.RotorOrders
+	if len(orders) == 0 {
+		rotors := opts.Rotors
+		if len(rotors) == 0 {
+			rotors = []string{"I", "II", "III", "IV", "V"}
+		}
+		orders = enigma.RotorOrders(rotors, 3)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	if opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = time.Minute
+	}
+
+	checkpoint := Checkpoint{
+		Keyspace: keyspace(menu, orders, opts),
+		Stops:    map[int][]SavedStop{},
+	}
+	results := make([][]Stop, len(orders))
+	if r := opts.Resume; r != nil {
+		if r.Keyspace != checkpoint.Keyspace {
+			return nil, fmt.Errorf("the checkpoint is for a different menu or options")
+		}
+		for i, saved := range r.Stops {
+			if i < 0 || i >= len(orders) {
+				return nil, fmt.Errorf("invalid checkpoint rotor order: %d", i)
+			}
+			stops, err := restoreStops(saved, orders[i], opts)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = stops
+			checkpoint.Stops[i] = saved
+		}
+	}
+
+	// the stops of each order are collected here, so the checkpoint is kept
+	// and saved by this goroutine alone
+	type ran struct {
+		order int
+		stops []Stop
+		err   error
+	}
+	left := []int{}
+	for i := range orders {
+		if _, ok := checkpoint.Stops[i]; !ok {
+			left = append(left, i)
+		}
+	}
+	found := make(chan ran)
+	go func() {
+		util.Work(context.Background(), left, workers, func(i int) {
+			stops, err := runOrder(menu, orders[i], opts)
+			found <- ran{order: i, stops: stops, err: err}
+		})
+		close(found)
+	}()
+
+	var checkpoints <-chan time.Time
+	if opts.Checkpoint != nil {
+		ticker := time.NewTicker(opts.CheckpointInterval)
+		defer ticker.Stop()
+		checkpoints = ticker.C
+	}
+	var err error
+	for done := false; !done; {
+		select {
+		case r, ok := <-found:
+			if !ok {
+				done = true
+				break
+			}
+			if r.err != nil {
+				if err == nil {
+					err = r.err
+				}
+				continue
+			}
+			results[r.order] = r.stops
+			checkpoint.Stops[r.order] = savedStops(r.stops)
+		case <-checkpoints:
+			opts.Checkpoint(checkpoint.clone())
+		}
+	}
+	if opts.Checkpoint != nil {
+		opts.Checkpoint(checkpoint.clone())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stops := []Stop{}
+	for i := range orders {
+		stops = append(stops, results[i]...)
+	}
+	return stops, nil
+}
+
+// runOrder runs the bombe through every start position of one rotor order.
+func runOrder(menu *Menu, order []string, opts Options) ([]Stop, error) {
+	ringSettings := ringSettings(order, opts)
+
+	scrambler, err := enigma.NewScrambler(enigma.Config{
+		Rotors:            order,
+		RotorRingSettings: ringSettings,
+		Reflector:         enigma.ReflectorConfig{Name: opts.Reflector},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	length := 0
+	for _, e := range menu.Edges {
+		length = max(length, e.Offset+1)
+	}
+
+	b := newTester(menu)
+	stops := []Stop{}
+	rows := make([][enigma.ALPHABET_SIZE]byte, length)
+	for position := 0; position < scrambler.Positions(); position++ {
+		// the scrambler at each offset of the crib, without the plugboard
+		scrambler.Rows(rows, position)
+		for i, e := range menu.Edges {
+			for x, y := range rows[e.Offset] {
+				b.scramblers[i][x] = int(y)
+			}
+		}
+
+		for _, steckers := range b.test() {
+			stops = append(stops, Stop{
+				Rotors:       order,
+				Reflector:    opts.Reflector,
+				RingSettings: ringSettings,
+				Position:     scrambler.PositionLetters(position),
+				Steckers:     steckers,
+			})
+		}
+	}
+
+	return stops, nil
+}
+
+// ringSettings returns the ring settings the bombe assumes for a rotor order.
+func ringSettings(order []string, opts Options) string {
+	if opts.RingSettings == "" {
+		return strings.Repeat("A", len(order))
+	}
+	return opts.RingSettings
+}
+
+// tester holds the menu wired up for a single setting of the scramblers.
+type tester struct {
+	central    int
+	edges      []Edge
+	adjacent   [26][]int // edges touching each letter
+	scramblers [][26]int // the scrambler permutation for each edge
+}
+
+func newTester(menu *Menu) *tester {
+	b := &tester{
+		central:    int(menu.Central - 'A'),
+		edges:      menu.Edges,
+		scramblers: make([][26]int, len(menu.Edges)),
+	}
+	for i, e := range menu.Edges {
+		b.adjacent[e.Plain-'A'] = append(b.adjacent[e.Plain-'A'], i)
+		b.adjacent[e.Cipher-'A'] = append(b.adjacent[e.Cipher-'A'], i)
+	}
+	return b
+}
+
+// test tries every plugboard partner for the central letter and returns the
+// steckers implied by each one that does not contradict itself.
+func (b *tester) test() []map[rune]rune {
+	found := []map[rune]rune{}
+	for partner := 0; partner < 26; partner++ {
+		if steckers, ok := b.hypothesis(partner); ok {
+			found = append(found, steckers)
+		}
+	}
+	return found
+}
+
+// hypothesis assumes the central letter is plugged to partner and spreads
+// the consequences. Like current flowing through the wires of the bombe,
+// every implied connection A-B lights B-A on the diagonal board and, through
+// each scrambler on the menu between A and C, the connection C-S(B). A
+// letter ending up connected to two different letters is a contradiction.
+func (b *tester) hypothesis(partner int) (map[rune]rune, bool) {
+	plug := [26]int{}
+	for i := range plug {
+		plug[i] = -1
+	}
+
+	type wire struct{ letter, to int }
+	queue := []wire{{b.central, partner}}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+
+		if plug[w.letter] == w.to {
+			continue
+		}
+		if plug[w.letter] != -1 {
+			return nil, false
+		}
+		plug[w.letter] = w.to
+
+		// diagonal board
+		queue = append(queue, wire{w.to, w.letter})
+
+		for _, i := range b.adjacent[w.letter] {
+			e := b.edges[i]
+			other := int(e.Cipher - 'A')
+			if other == w.letter {
+				other = int(e.Plain - 'A')
+			}
+			queue = append(queue, wire{other, b.scramblers[i][w.to]})
+		}
+	}
+
+	steckers := map[rune]rune{}
+	for _, e := range b.edges {
+		for _, letter := range []int{int(e.Plain - 'A'), int(e.Cipher - 'A')} {
+			// letters on parts of the menu not joined to the central
+			// letter are not reached
+			if plug[letter] == -1 {
+				continue
+			}
+			steckers[rune('A'+letter)] = rune('A' + plug[letter])
+			steckers[rune('A'+plug[letter])] = rune('A' + letter)
+		}
+	}
+	return steckers, true
+}
@@ -0,0 +1,194 @@
+package bombe
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+func TestNewMenu(t *testing.T) {
+	menu, err := NewMenu("QWERT YUIOP", "ABCDE", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(menu.Edges) != 5 {
+		t.Fatalf("expected 5 edges, got %d", len(menu.Edges))
+	}
+	first := menu.Edges[0]
+	if first.Plain != 'A' || first.Cipher != 'R' || first.Offset != 3 {
+		t.Fatalf("expected A-R at offset 3, got %c-%c at %d", first.Plain, first.Cipher, first.Offset)
+	}
+}
+
+func TestNewMenu_Central(t *testing.T) {
+	// E appears on three edges
+	menu, err := NewMenu("ABEZ", "EEXE", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if menu.Central != 'E' {
+		t.Fatalf("expected central letter E, got %c", menu.Central)
+	}
+	if got := string(menu.Letters()); got != "ABEXZ" {
+		t.Fatalf("expected letters ABEXZ, got %s", got)
+	}
+}
+
+func TestNewMenu_Invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		ciphertext string
+		crib       string
+		offset     int
+	}{
+		{"self encryption", "ABCDE", "XBX", 0},
+		{"too long", "ABCDE", "XYZ", 3},
+		{"negative offset", "ABCDE", "XYZ", -1},
+		{"empty crib", "ABCDE", "", 0},
+		{"bad letter", "ABCDE", "X1", 0},
+	}
+
+	for _, test := range tests {
+		if _, err := NewMenu(test.ciphertext, test.crib, test.offset); err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		}
+	}
+}
+
+func TestMenu_Closures(t *testing.T) {
+	// A-B, B-C, C-A is one loop, D-E adds none
+	menu := &Menu{Edges: []Edge{
+		{Plain: 'A', Cipher: 'B'},
+		{Plain: 'B', Cipher: 'C'},
+		{Plain: 'C', Cipher: 'A'},
+		{Plain: 'D', Cipher: 'E'},
+	}}
+	if closures := menu.Closures(); closures != 1 {
+		t.Fatalf("expected 1 closure, got %d", closures)
+	}
+}
+
+func TestRun_FindsKey(t *testing.T) {
+	config := enigma.Config{
+		Rotors:         []string{"II", "V", "III"},
+		RotorPositions: "KDO",
+		Reflector:      enigma.ReflectorConfig{Name: "B"},
+		Plugboard: enigma.PlugboardConfig{
+			Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ", "IN", "KM", "OW", "RX"},
+		},
+	}
+	em, err := config.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := "WETTERVORHERSAGEBISKAYA"
+	ciphertext, err := em.EncryptString(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	menu, err := NewMenu(ciphertext, plaintext, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stops, err := Run(menu, Options{RotorOrders: [][]string{config.Rotors}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found *Stop
+	for i, stop := range stops {
+		if stop.Position == config.RotorPositions {
+			found = &stops[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("expected a stop at %s, got %d stops", config.RotorPositions, len(stops))
+	}
+	if len(stops) > 20 {
+		t.Errorf("expected few false stops with this menu, got %d", len(stops))
+	}
+
+	// every implied stecker must agree with the real plugboard
+	plugboard := em.GetPlugboardConnections()
+	for a, b := range found.Steckers {
+		want, ok := plugboard[a]
+		if !ok {
+			want = a
+		}
+		if b != want {
+			t.Errorf("stop implies %c-%c, the plugboard has %c-%c", a, b, a, want)
+		}
+	}
+
+	if got := strings.Join(found.Rotors, ","); got != "II,V,III" {
+		t.Errorf("expected rotors II,V,III, got %s", got)
+	}
+}
+
+func TestRun_EmptyMenu(t *testing.T) {
+	if _, err := Run(&Menu{}, Options{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestRun_Resume(t *testing.T) {
+	menu, err := NewMenu("SBOUHZMHKTCEGTEPAJLOQXN", "WETTERVORHERSAGE", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{RotorOrders: [][]string{{"II", "V", "III"}, {"V", "II", "III"}}, Workers: 1}
+	want, err := Run(menu, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keep a checkpoint taken after the first order, as if the run had been
+	// stopped there
+	var checkpoint []byte
+	stopped := opts
+	stopped.CheckpointInterval = time.Millisecond
+	stopped.Checkpoint = func(c Checkpoint) {
+		if len(c.Stops) == 1 {
+			data, err := json.Marshal(c)
+			if err != nil {
+				t.Error(err)
+			}
+			checkpoint = data
+		}
+	}
+	if _, err := Run(menu, stopped); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint == nil {
+		t.Fatal("expected a checkpoint with one rotor order run")
+	}
+
+	resume := &Checkpoint{}
+	if err := json.Unmarshal(checkpoint, resume); err != nil {
+		t.Fatal(err)
+	}
+	resumed := opts
+	resumed.Resume = resume
+	got, err := Run(menu, resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed stops = %v, want %v", got, want)
+	}
+
+	other := opts
+	other.RingSettings = "AAB"
+	other.Resume = resume
+	if _, err := Run(menu, other); err == nil {
+		t.Error("expected an error resuming with different options")
+	}
+}
@@ -0,0 +1,87 @@
+package bombe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Checkpoint is the state of a bombe run, written out so a long run can be
+// stopped and resumed. Only rotor orders that were run through are recorded,
+// so a resumed run gives the same stops as one that was never stopped.
+type Checkpoint struct {
+	// Keyspace identifies the menu and options the run was started with.
+	Keyspace string `json:"keyspace"`
+	// Stops holds the stops of each rotor order run, by the index of the
+	// order.
+	Stops map[int][]SavedStop `json:"stops"`
+}
+
+// SavedStop is a stop in a checkpoint.
+type SavedStop struct {
+	Position string `json:"position"`
+	// Steckers pairs each letter with the partner the stop implies, such
+	// as AV, or EE for an unplugged E.
+	Steckers []string `json:"steckers"`
+}
+
+func (c Checkpoint) clone() Checkpoint {
+	return Checkpoint{Keyspace: c.Keyspace, Stops: maps.Clone(c.Stops)}
+}
+
+func savedStops(stops []Stop) []SavedStop {
+	saved := make([]SavedStop, len(stops))
+	for i, stop := range stops {
+		saved[i].Position = stop.Position
+		for a, b := range stop.Steckers {
+			saved[i].Steckers = append(saved[i].Steckers, string([]rune{a, b}))
+		}
+		slices.Sort(saved[i].Steckers)
+	}
+	return saved
+}
+
+// restoreStops rebuilds the saved stops of a rotor order.
+func restoreStops(saved []SavedStop, order []string, opts Options) ([]Stop, error) {
+	stops := make([]Stop, len(saved))
+	for i, s := range saved {
+		if len(s.Position) != len(order) {
+			return nil, fmt.Errorf("invalid checkpoint position %q", s.Position)
+		}
+		steckers := map[rune]rune{}
+		for _, pair := range s.Steckers {
+			if len(pair) != 2 || !isLetter(pair[0]) || !isLetter(pair[1]) {
+				return nil, fmt.Errorf("invalid checkpoint stecker %q", pair)
+			}
+			steckers[rune(pair[0])] = rune(pair[1])
+		}
+		stops[i] = Stop{
+			Rotors:       order,
+			Reflector:    opts.Reflector,
+			RingSettings: ringSettings(order, opts),
+			Position:     s.Position,
+			Steckers:     steckers,
+		}
+	}
+	return stops, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+// keyspace returns a digest of the menu and the options that decide the
+// stops of a run.
+func keyspace(menu *Menu, orders [][]string, opts Options) string {
+	data, _ := json.Marshal(struct {
+		Menu         *Menu
+		Orders       [][]string
+		Reflector    string
+		RingSettings string
+	}{menu, orders, opts.Reflector, opts.RingSettings})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package bombe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// Edge links a crib letter to the ciphertext letter below it. Offset is the
+// index of the letter in the ciphertext, so the machine had stepped Offset+1
+// times from the start position when it was encrypted.
+type Edge struct {
+	Plain  rune
+	Cipher rune
+	Offset int
+}
+
+// Menu is the graph of letters connected by the scrambler at different
+// offsets, derived from a crib placed under the ciphertext. The bombe tests
+// hypotheses about the plugboard partner of the central letter, the letter
+// with the most connections.
+type Menu struct {
+	Edges   []Edge
+	Central rune
+}
+
+// NewMenu builds the menu for a crib placed under the ciphertext starting at
+// offset. Spaces are ignored in both. Since the Enigma never encrypts a letter
+// to itself, an alignment where a crib letter matches the ciphertext letter
+// below it is impossible and returns an error.
+func NewMenu(ciphertext, crib string, offset int) (*Menu, error) {
+	ciphertext, err := enigma.Normalize(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	crib, err = enigma.Normalize(crib)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(crib) == 0 {
+		return nil, fmt.Errorf("the crib is empty")
+	}
+	if offset < 0 || offset+len(crib) > len(ciphertext) {
+		return nil, fmt.Errorf("crib of length %d does not fit the ciphertext of length %d at offset %d", len(crib), len(ciphertext), offset)
+	}
+
+	m := &Menu{}
+	connections := map[rune]int{}
+	for i := range crib {
+		plain, cipher := rune(crib[i]), rune(ciphertext[offset+i])
+		if plain == cipher {
+			return nil, fmt.Errorf("crib cannot sit at offset %d: %c would encrypt to itself", offset, plain)
+		}
+		m.Edges = append(m.Edges, Edge{Plain: plain, Cipher: cipher, Offset: offset + i})
+		connections[plain]++
+		connections[cipher]++
+	}
+
+	// the central letter is the best connected one, ties go to the earliest
+	// letter in the alphabet so menus are stable
+	for letter := 'A'; letter <= 'Z'; letter++ {
+		if connections[letter] > connections[m.Central] {
+			m.Central = letter
+		}
+	}
+
+	return m, nil
+}
+
+// Letters returns the distinct letters on the menu in alphabetical order.
+func (m *Menu) Letters() []rune {
+	seen := [26]bool{}
+	for _, e := range m.Edges {
+		seen[e.Plain-'A'] = true
+		seen[e.Cipher-'A'] = true
+	}
+	letters := []rune{}
+	for i, ok := range seen {
+		if ok {
+			letters = append(letters, rune('A'+i))
+		}
+	}
+	return letters
+}
+
+// Closures returns the number of independent loops in the menu. Every loop
+// is a constraint a wrong setting is unlikely to satisfy, so menus with more
+// loops give fewer false stops.
+func (m *Menu) Closures() int {
+	parent := map[rune]rune{}
+	var find func(r rune) rune
+	find = func(r rune) rune {
+		if p, ok := parent[r]; ok && p != r {
+			root := find(p)
+			parent[r] = root
+			return root
+		}
+		parent[r] = r
+		return r
+	}
+
+	closures := 0
+	for _, e := range m.Edges {
+		a, b := find(e.Plain), find(e.Cipher)
+		if a == b {
+			closures++
+			continue
+		}
+		parent[a] = b
+	}
+	return closures
+}
+
+func (m *Menu) String() string {
+	var b strings.Builder
+	for i, e := range m.Edges {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%c-%d-%c", e.Plain, e.Offset+1, e.Cipher)
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package enigma
+
+import (
+	"strings"
+	"testing"
+)
+
+var benchMessage = strings.Repeat("THEQUICKBROWNFOXJUMPSOVERTHELAZYDOG", 30)
+
+func setupBenchMachine(b *testing.B) *EnigmaMachine {
+	em, err := Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorPositions:    "BLE",
+		RotorRingSettings: "XAN",
+		Reflector:         ReflectorConfig{Name: "B"},
+		Plugboard:         PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ", "IN", "KM", "OW", "RX"}},
+	}.Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return em
+}
+
+func BenchmarkEncryptString(b *testing.B) {
+	em := setupBenchMachine(b)
+	b.SetBytes(int64(len(benchMessage)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		em.Reset()
+		if _, err := em.EncryptString(benchMessage); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptString(b *testing.B) {
+	em := setupBenchMachine(b)
+	b.SetBytes(int64(len(benchMessage)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		em.Reset()
+		if _, err := em.DecryptString(benchMessage); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptLetter(b *testing.B) {
+	em := setupBenchMachine(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := em.encrypt('A' + rune(i%26)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptIndices(b *testing.B) {
+	em := setupBenchMachine(b)
+	indices := make([]byte, len(benchMessage))
+	b.SetBytes(int64(len(benchMessage)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for n := range indices {
+			indices[n] = benchMessage[n] - 'A'
+		}
+		em.Reset()
+		if err := em.EncryptIndices(indices); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewScrambler(b *testing.B) {
+	config := Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorRingSettings: "XAN",
+		Reflector:         ReflectorConfig{Name: "B"},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewScrambler(config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScramblerEncryptIndices(b *testing.B) {
+	em := setupBenchMachine(b)
+	s, err := NewScrambler(Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorRingSettings: "XAN",
+		Reflector:         ReflectorConfig{Name: "B"},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	plugboard := em.plugboard.table
+	indices := make([]byte, len(benchMessage))
+	b.SetBytes(int64(len(benchMessage)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for n := range indices {
+			indices[n] = benchMessage[n] - 'A'
+		}
+		if _, err := s.EncryptIndices(indices, i%s.Positions(), &plugboard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
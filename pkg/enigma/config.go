@@ -21,9 +21,32 @@ const (
 	ROTOR_V_WIRING = "VZBRGITYUPSDNHLXAWMJQOFECK"
 	ROTOR_V_NOTCH  = 'Z'
 
+	// the Kriegsmarine rotors turn over at both Z and M
+	ROTOR_VI_WIRING  = "JPGVOUMFYQBENHZRDKASXLICTW"
+	ROTOR_VI_NOTCHES = "ZM"
+
+	ROTOR_VII_WIRING  = "NZJHGRCXMYSWBOUFAIVLPEKQDT"
+	ROTOR_VII_NOTCHES = "ZM"
+
+	ROTOR_VIII_WIRING  = "FKQHTLXOCBJSPDZRAMEWNIUYGV"
+	ROTOR_VIII_NOTCHES = "ZM"
+
+	// the thin rotors of the M4, they sit left of the three stepping rotors
+	// and never move
+	ROTOR_BETA_WIRING  = "LEYJVCNIXWPBQMDRTAKZGFUHOS"
+	ROTOR_GAMMA_WIRING = "FSOKANUERHMBTIYCWLQPZXVGJD"
+
 	REFLECTOR_A_WIRING = "EJMZALYXVBWFCRQUONTSPIKHGD"
 	REFLECTOR_B_WIRING = "YRUHQSLDPXNGOKMIEBFZCWVJAT"
 	REFLECTOR_C_WIRING = "FVPJIAOYEDRZXWGCTKUQSBNMHL"
+
+	// the rewirable UKW-D always connects B and O (German notation), the
+	// other 12 pairs are set by the operator
+	REFLECTOR_D_FIXED_PAIR = "BO"
+
+	// the thin reflectors of the M4 (UKW-B dünn and UKW-C dünn)
+	REFLECTOR_B_THIN_WIRING = "ENKQAUYWJICOPBLMDXZVFTHRGS"
+	REFLECTOR_C_THIN_WIRING = "RDOBJNTKVEHMLFCWZAXGYIPSUQ"
 )
 
 func CreateRotorI() (*Rotor, error) {
@@ -56,6 +79,34 @@ func CreateRotorV() (*Rotor, error) {
 	return NewRotor(wiring, notch)
 }
 
+func CreateRotorVI() (*Rotor, error) {
+	wiring := []rune(ROTOR_VI_WIRING)
+	notches := []rune(ROTOR_VI_NOTCHES)
+	return NewRotor(wiring, notches...)
+}
+
+func CreateRotorVII() (*Rotor, error) {
+	wiring := []rune(ROTOR_VII_WIRING)
+	notches := []rune(ROTOR_VII_NOTCHES)
+	return NewRotor(wiring, notches...)
+}
+
+func CreateRotorVIII() (*Rotor, error) {
+	wiring := []rune(ROTOR_VIII_WIRING)
+	notches := []rune(ROTOR_VIII_NOTCHES)
+	return NewRotor(wiring, notches...)
+}
+
+func CreateRotorBeta() (*Rotor, error) {
+	wiring := []rune(ROTOR_BETA_WIRING)
+	return NewThinRotor(wiring)
+}
+
+func CreateRotorGamma() (*Rotor, error) {
+	wiring := []rune(ROTOR_GAMMA_WIRING)
+	return NewThinRotor(wiring)
+}
+
 func CreateReflectorA() (*Reflector, error) {
 	wiring := []rune(REFLECTOR_A_WIRING)
 	return newReflector(wiring)
@@ -71,7 +122,40 @@ func CreateReflectorC() (*Reflector, error) {
 	return newReflector(wiring)
 }
 
+func CreateReflectorBThin() (*Reflector, error) {
+	wiring := []rune(REFLECTOR_B_THIN_WIRING)
+	return newReflector(wiring)
+}
+
+func CreateReflectorCThin() (*Reflector, error) {
+	wiring := []rune(REFLECTOR_C_THIN_WIRING)
+	return newReflector(wiring)
+}
+
+// CreateReflectorD creates the rewirable reflector D from its 12 pairs, given
+// as two letter strings in the German notation where B and O are always
+// connected, see NewRewirableReflector.
+func CreateReflectorD(pairs []string) (*Reflector, error) {
+	r, err := NewRewirableReflector(pairs)
+	if err != nil {
+		return nil, err
+	}
+	r.name = "D"
+	return r, nil
+}
+
+// CreateReflectorFromSelection creates one of the fixed reflectors. The
+// rewirable reflector D needs its pairs, see CreateReflectorD.
 func CreateReflectorFromSelection(selection string) (*Reflector, error) {
+	r, err := createReflector(selection)
+	if err != nil {
+		return nil, err
+	}
+	r.name = selection
+	return r, nil
+}
+
+func createReflector(selection string) (*Reflector, error) {
 	switch selection {
 	case "A":
 		return CreateReflectorA()
@@ -79,12 +163,27 @@ func CreateReflectorFromSelection(selection string) (*Reflector, error) {
 		return CreateReflectorB()
 	case "C":
 		return CreateReflectorC()
+	case "B-thin":
+		return CreateReflectorBThin()
+	case "C-thin":
+		return CreateReflectorCThin()
+	case "D":
+		return nil, fmt.Errorf("reflector D needs a wiring")
 	default:
 		return nil, fmt.Errorf("invalid reflector: %s", selection)
 	}
 }
 
 func CreateRotorFromSelection(selection string) (*Rotor, error) {
+	r, err := createRotor(selection)
+	if err != nil {
+		return nil, err
+	}
+	r.name = selection
+	return r, nil
+}
+
+func createRotor(selection string) (*Rotor, error) {
 	switch selection {
 	case "I":
 		return CreateRotorI()
@@ -96,6 +195,16 @@ func CreateRotorFromSelection(selection string) (*Rotor, error) {
 		return CreateRotorIV()
 	case "V":
 		return CreateRotorV()
+	case "VI":
+		return CreateRotorVI()
+	case "VII":
+		return CreateRotorVII()
+	case "VIII":
+		return CreateRotorVIII()
+	case "Beta":
+		return CreateRotorBeta()
+	case "Gamma":
+		return CreateRotorGamma()
 	default:
 		return nil, fmt.Errorf("invalid rotor: %s", selection)
 	}
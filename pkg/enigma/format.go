@@ -0,0 +1,70 @@
+package enigma
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// FORMAT_GROUPS writes the letters in groups of five, as ciphertext was
+	// sent. It is the format of EncryptString.
+	FORMAT_GROUPS = "groups"
+	// FORMAT_LETTERS writes the letters without spacing. It is the format of
+	// DecryptString.
+	FORMAT_LETTERS = "letters"
+	// FORMAT_PRESERVE keeps every character that is not a letter from A to Z
+	// where it was, and the case of the letters. The rotors only step for
+	// letters, so encrypting and decrypting in this format gives back the
+	// text exactly.
+	FORMAT_PRESERVE = "preserve"
+)
+
+// EncryptFormat encrypts a message and writes the ciphertext in the format
+// given, FORMAT_GROUPS when it is empty.
+func (e *EnigmaMachine) EncryptFormat(message, format string) (string, error) {
+	if format == "" {
+		format = FORMAT_GROUPS
+	}
+	return e.transformFormat(message, format)
+}
+
+// DecryptFormat decrypts a ciphertext and writes the plaintext in the format
+// given, FORMAT_LETTERS when it is empty.
+func (e *EnigmaMachine) DecryptFormat(ciphertext, format string) (string, error) {
+	if format == "" {
+		format = FORMAT_LETTERS
+	}
+	return e.transformFormat(ciphertext, format)
+}
+
+func (e *EnigmaMachine) transformFormat(message, format string) (string, error) {
+	switch format {
+	case FORMAT_GROUPS:
+		return e.EncryptString(message)
+	case FORMAT_LETTERS:
+		return e.transformString(message)
+	case FORMAT_PRESERVE:
+		return e.transformPreserve(message), nil
+	default:
+		return "", fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// transformPreserve encrypts the letters of a message, keeping their case,
+// and passes every other byte through without stepping the rotors. It works
+// on bytes like the stream encrypter, so invalid UTF-8 is copied unchanged.
+func (e *EnigmaMachine) transformPreserve(message string) string {
+	var out strings.Builder
+	out.Grow(len(message))
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + e.encryptIndex(c-'A')
+		case c >= 'a' && c <= 'z':
+			c = 'a' + e.encryptIndex(c-'a')
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
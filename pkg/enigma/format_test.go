@@ -0,0 +1,98 @@
+package enigma
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestEnigmaMachine_EncryptFormat(t *testing.T) {
+	message := "Hello, world! Ça va? 42"
+
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	letters, err := em.DecryptString("HELLOWORLDAVA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := letters[:5] + " " + letters[5:10]
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"", groups},
+		{FORMAT_GROUPS, groups},
+		{FORMAT_LETTERS, letters[:10]},
+	}
+	for _, tt := range tests {
+		em, err := setupEnigmaMachine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := em.EncryptFormat("hello world", tt.format)
+		if err != nil {
+			t.Fatalf("EncryptFormat(%q) error: %v", tt.format, err)
+		}
+		if got != tt.expected {
+			t.Errorf("EncryptFormat(%q) = %q, want %q", tt.format, got, tt.expected)
+		}
+	}
+
+	em, err = setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := em.EncryptFormat(message, FORMAT_PRESERVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// non-letters stay in place, letters keep their case and step the
+	// rotors as if typed on their own
+	if len([]rune(encrypted)) != len([]rune(message)) {
+		t.Fatalf("EncryptFormat() = %q, want the length of %q", encrypted, message)
+	}
+	plain, cipher := []rune(message), []rune(encrypted)
+	var got strings.Builder
+	for i, r := range plain {
+		isLetter := (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z')
+		if !isLetter {
+			if cipher[i] != r {
+				t.Errorf("character %d = %c, want %c passed through", i, cipher[i], r)
+			}
+			continue
+		}
+		if unicode.IsUpper(r) != unicode.IsUpper(cipher[i]) {
+			t.Errorf("character %d = %c, want the case of %c", i, cipher[i], r)
+		}
+		got.WriteRune(unicode.ToUpper(cipher[i]))
+	}
+	if got.String() != letters {
+		t.Errorf("letters of EncryptFormat() = %s, want %s", got.String(), letters)
+	}
+
+	em.Reset()
+	decrypted, err := em.DecryptFormat(encrypted, FORMAT_PRESERVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != message {
+		t.Errorf("DecryptFormat() = %q, want %q", decrypted, message)
+	}
+}
+
+func TestEnigmaMachine_EncryptFormat_Invalid(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := em.EncryptFormat("hello", "fancy"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := em.DecryptFormat("hello, world", FORMAT_LETTERS); err == nil {
+		t.Error("expected an error for punctuation outside the preserve format")
+	}
+}
@@ -9,6 +9,8 @@ type EnigmaMachine struct {
 	plugboard *Plugboard
 	rotors    []*Rotor
 	reflector *Reflector
+	// startPositions are the rotor positions Reset returns to
+	startPositions []int
 }
 
 func NewEnigmaMachine(
@@ -16,102 +18,190 @@ func NewEnigmaMachine(
 	rotors []*Rotor,
 	reflector *Reflector,
 ) *EnigmaMachine {
-	return &EnigmaMachine{
+	e := &EnigmaMachine{
 		plugboard: plugboard,
 		rotors:    rotors,
 		reflector: reflector,
 	}
+	e.startPositions = e.GetRotorPositions()
+	return e
 }
 
 func (e *EnigmaMachine) encrypt(letter rune) (rune, error) {
 	if letter < 'A' || letter > 'Z' {
 		return 0, fmt.Errorf("invalid letter: %c", letter)
 	}
+	return alphabetIndexToRune(int(e.encryptIndex(byte(runeToAlphabetIndex(letter))))), nil
+}
 
-	// rotate rotors
-	rotateNext := true // the rightmost rotor always rotates on key press
-	for i := len(e.rotors) - 1; i >= 0; i-- {
-		rotor := e.rotors[i]
-		if rotateNext {
-			rotateNext = rotor.rotate()
-		} else {
-			break
+// EncryptIndices encrypts letters given as indices, 0 for A to 25 for Z, in
+// place, stepping the rotors for each one like EncryptString. It is the fast
+// path for cryptanalysis: it does not allocate, and the indices are checked
+// before the machine is touched.
+func (e *EnigmaMachine) EncryptIndices(indices []byte) error {
+	for _, i := range indices {
+		if i >= ALPHABET_SIZE {
+			return fmt.Errorf("invalid letter index: %d", i)
 		}
 	}
+	for n, i := range indices {
+		indices[n] = e.encryptIndex(i)
+	}
+	return nil
+}
+
+// encryptIndex steps the rotors and passes a valid letter index through the
+// machine, using the wiring tables of every component.
+func (e *EnigmaMachine) encryptIndex(i byte) byte {
+	e.stepRotors()
+	return e.plugboard.table[e.scrambleIndex(e.plugboard.table[i])]
+}
+
+// scrambleIndex passes a letter index through the rotors, the reflector and
+// back, with the rotors where they stand.
+func (e *EnigmaMachine) scrambleIndex(i byte) byte {
+	for n := len(e.rotors) - 1; n >= 0; n-- {
+		i = e.rotors[n].forwardIndex(i)
+	}
+	i = e.reflector.table[i]
+	for _, rotor := range e.rotors {
+		i = rotor.backwardIndex(i)
+	}
+	return i
+}
+
+// encryptTraced encrypts a letter, recording every hop of the signal in trace
+// unless it is nil. It goes through each component letter by letter, so the
+// hops can be recorded, and is slower than encrypt.
+func (e *EnigmaMachine) encryptTraced(letter rune, trace *Trace) (rune, error) {
+	if letter < 'A' || letter > 'Z' {
+		return 0, fmt.Errorf("invalid letter: %c", letter)
+	}
+
+	// rotate rotors
+	e.stepRotors()
 
 	// step 1: plugboard
 	transformed, err := e.plugboard.transform(letter)
 	if err != nil {
 		return 0, err
 	}
+	if trace != nil {
+		*trace = Trace{
+			Key:         letter,
+			Positions:   e.positionLetters(),
+			PlugboardIn: Hop{Name: "plugboard", In: letter, Out: transformed},
+		}
+	}
 
 	// step 2: rotors forward
 	for i := len(e.rotors) - 1; i >= 0; i-- {
 		rotor := e.rotors[i]
+		in := transformed
 		transformed, err = rotor.transformForward(transformed)
 		if err != nil {
 			return 0, err
 		}
-
+		if trace != nil {
+			trace.Forward = append(trace.Forward, newRotorHop(i, rotor, in, transformed))
+		}
 	}
 
 	// step 3: reflector
+	in := transformed
 	transformed, err = e.reflector.transform(transformed)
 	if err != nil {
 		return 0, err
 	}
+	if trace != nil {
+		trace.Reflector = Hop{Name: e.reflector.name, In: in, Out: transformed}
+	}
 
 	// step 4: rotors backward
-	for _, rotor := range e.rotors {
+	for i, rotor := range e.rotors {
+		in := transformed
 		transformed, err = rotor.transformBackward(transformed)
 		if err != nil {
 			return 0, err
 		}
-
+		if trace != nil {
+			trace.Backward = append(trace.Backward, newRotorHop(i, rotor, in, transformed))
+		}
 	}
 
 	// step 5: plugboard
+	in = transformed
 	transformed, err = e.plugboard.transform(transformed)
 	if err != nil {
 		return 0, err
 	}
+	if trace != nil {
+		trace.PlugboardOut = Hop{Name: "plugboard", In: in, Out: transformed}
+		trace.Lamp = transformed
+	}
 
 	return transformed, nil
 }
 
-func (e *EnigmaMachine) normailizeMessage(message string) (string, error) {
-	normalizedMessage := ""
-	message = strings.ToUpper(message)
-	message = strings.ReplaceAll(message, " ", "")
-	for _, letter := range message {
-		if letter >= 'a' && letter <= 'z' {
-			letter -= 'a' - 'A'
+// stepRotors advances the rotors the way the pawl and ratchet mechanism of a
+// real machine does. Each pawl sits between two rotors: it always pushes the
+// ratchet of the rotor on its left when the notch of the rotor on its right is
+// lined up, and since it drops into that notch it pushes the right rotor along
+// with it. This is what makes the middle rotor step twice in a row (the double
+// step), e.g. ADU -> ADV -> AEW -> BFX for rotors I, II, III.
+//
+// Every pawl is evaluated against the positions from before the key press, so
+// all rotors move at once. Fixed rotors have no pawl on either side of them.
+func (e *EnigmaMachine) stepRotors() {
+	last := len(e.rotors) - 1
+	if last < 0 {
+		return
+	}
+
+	// one bit per rotor, so working out the steps does not allocate
+	var steps uint64
+	steps |= 1 << last // the rightmost rotor always rotates on key press
+	for i := last - 1; i >= 0; i-- {
+		if e.rotors[i].fixed || e.rotors[i+1].fixed {
+			continue
+		}
+		if e.rotors[i+1].atNotch() {
+			steps |= 1<<i | 1<<(i+1)
 		}
-		if letter < 'A' || letter > 'Z' {
-			return "", fmt.Errorf("invalid letter: %c", letter)
+	}
+
+	for i, rotor := range e.rotors {
+		if steps&(1<<i) != 0 && !rotor.fixed {
+			rotor.position = (rotor.position + 1) % ALPHABET_SIZE
 		}
-		normalizedMessage += string(letter)
 	}
-	return normalizedMessage, nil
 }
 
-func (e *EnigmaMachine) normailzeOutput(output string) string {
-	if len(output) == 0 {
-		return ""
+func (e *EnigmaMachine) normailizeMessage(message string) (string, error) {
+	normalized, err := normalizeIndices(message)
+	if err != nil {
+		return "", err
+	}
+	for i := range normalized {
+		normalized[i] += 'A'
 	}
+	return string(normalized), nil
+}
+
+func (e *EnigmaMachine) normailzeOutput(output string) string {
 	if len(output) <= 5 {
 		return output
 	}
 	// split output into groups of 5 characters
-	groups := []string{}
+	var groups strings.Builder
+	groups.Grow(len(output) + len(output)/5)
 	for i := 0; i < len(output); i += 5 {
-		end := i + 5
-		if end > len(output) {
-			end = len(output)
+		if i > 0 {
+			groups.WriteByte(' ')
 		}
-		groups = append(groups, output[i:end])
+		groups.WriteString(output[i:min(i+5, len(output))])
 	}
-	return strings.Join(groups, " ")
+	return groups.String()
 }
 
 func (e *EnigmaMachine) SetRotorPositions(positions []string) error {
@@ -125,6 +215,7 @@ func (e *EnigmaMachine) SetRotorPositions(positions []string) error {
 		}
 	}
 
+	e.startPositions = e.GetRotorPositions()
 	return nil
 }
 
@@ -194,17 +285,41 @@ func (e *EnigmaMachine) ClearPlugboardConnections() {
 }
 
 func (e *EnigmaMachine) EncryptString(message string) (string, error) {
-	var result strings.Builder
-	message, err := e.normailizeMessage(message)
+	result, err := e.transformString(message)
 	if err != nil {
 		return "", err
 	}
-	for _, letter := range message {
-		encryptedLetter, err := e.encrypt(letter)
-		if err != nil {
-			return "", err
-		}
-		result.WriteRune(encryptedLetter)
+	return e.normailzeOutput(result), nil
+}
+
+// DecryptString decrypts a ciphertext, ignoring any spacing such as the five
+// letter groups, and returns the plaintext without spacing.
+//
+// The Enigma is reciprocal: since the signal passes through the reflector,
+// which pairs letters up, a machine that turns A into F at some position
+// turns F into A at the same position. Decrypting is therefore the same as
+// encrypting from the same starting settings, and for any settings
+// DecryptString(EncryptString(m)) returns m in upper case without spaces.
+func (e *EnigmaMachine) DecryptString(ciphertext string) (string, error) {
+	return e.transformString(ciphertext)
+}
+
+func (e *EnigmaMachine) transformString(message string) (string, error) {
+	indices, err := normalizeIndices(message)
+	if err != nil {
+		return "", err
 	}
-	return e.normailzeOutput(result.String()), nil
+	for n, i := range indices {
+		indices[n] = byte(alphabetIndexToRune(int(e.encryptIndex(i))))
+	}
+	return string(indices), nil
+}
+
+// RecoverWords splits a decrypted message into words at the X used by
+// operators in place of spaces and punctuation. Real X letters are lost, as
+// they were for the people reading the message.
+func RecoverWords(plaintext string) string {
+	return strings.Join(strings.FieldsFunc(plaintext, func(r rune) bool {
+		return r == 'X' || r == 'x' || r == ' '
+	}), " ")
 }
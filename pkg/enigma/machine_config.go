@@ -0,0 +1,252 @@
+package enigma
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// The formats a Config can be marshaled to and unmarshaled from.
+const (
+	CONFIG_FORMAT_JSON = "json"
+	CONFIG_FORMAT_YAML = "yaml"
+	CONFIG_FORMAT_TOML = "toml"
+)
+
+// Config describes how to set up a machine. It uses the same schema as the
+// config file of the CLI, so daily settings can be shared between both:
+//
+//	model: M3
+//	rotors: [III, II, I]
+//	rotor-positions: AAA
+//	rotor-ring-settings: AAA
+//	reflector: B
+//	plugboard:
+//	  pairs: [AB, CD, EF]
+//
+// Rotors are listed from left to right. Empty rotor positions or ring
+// settings mean A for every rotor.
+type Config struct {
+	Model             string          `json:"model,omitempty" yaml:"model,omitempty"`
+	Rotors            []string        `json:"rotors" yaml:"rotors"`
+	RotorPositions    string          `json:"rotor-positions,omitempty" yaml:"rotor-positions,omitempty"`
+	RotorRingSettings string          `json:"rotor-ring-settings,omitempty" yaml:"rotor-ring-settings,omitempty"`
+	Reflector         ReflectorConfig `json:"reflector" yaml:"reflector"`
+	Plugboard         PlugboardConfig `json:"plugboard" yaml:"plugboard"`
+}
+
+// ReflectorConfig names the reflector. Only the rewirable reflector D has a
+// wiring, any other reflector is written as just its name.
+type ReflectorConfig struct {
+	Name   string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Wiring []string `json:"wiring,omitempty" yaml:"wiring,omitempty"`
+}
+
+type PlugboardConfig struct {
+	Pairs []string `json:"pairs" yaml:"pairs"`
+}
+
+// DefaultConfig returns the settings the CLI uses when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		Rotors:            []string{"III", "II", "I"},
+		RotorPositions:    "AAA",
+		RotorRingSettings: "AAA",
+		Reflector:         ReflectorConfig{Name: "B"},
+		Plugboard:         PlugboardConfig{Pairs: []string{}},
+	}
+}
+
+// Validate checks that a machine can be built from the config.
+func (c Config) Validate() error {
+	_, err := c.Build()
+	return err
+}
+
+// Build creates a machine set up with the config.
+func (c Config) Build() (*EnigmaMachine, error) {
+	if len(c.Rotors) == 0 {
+		return nil, fmt.Errorf("no rotors selected")
+	}
+
+	reflectorName := c.Reflector.name()
+	if err := ValidateModelSelection(c.Model, c.Rotors, reflectorName); err != nil {
+		return nil, err
+	}
+
+	positions := c.rotorPositions()
+	if len(c.Rotors) != len(positions) {
+		return nil, fmt.Errorf("rotor selection and rotor positions must have the same length")
+	}
+
+	ringSettings := c.rotorRingSettings()
+	if len(c.Rotors) != len(ringSettings) {
+		return nil, fmt.Errorf("rotor selection and rotor ring settings must have the same length")
+	}
+
+	if len(c.Plugboard.Pairs) > 10 {
+		return nil, fmt.Errorf("plugboard pairs must be 10 or fewer")
+	}
+
+	var reflector *Reflector
+	var err error
+	if reflectorName == "D" {
+		reflector, err = CreateReflectorD(c.Reflector.Wiring)
+	} else {
+		if len(c.Reflector.Wiring) > 0 {
+			return nil, fmt.Errorf("reflector %s cannot be rewired", reflectorName)
+		}
+		reflector, err = CreateReflectorFromSelection(reflectorName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rotors := make([]*Rotor, len(c.Rotors))
+	for i, rotorName := range c.Rotors {
+		rotor, err := CreateRotorFromSelection(rotorName)
+		if err != nil {
+			return nil, err
+		}
+		rotors[i] = rotor
+	}
+
+	em := NewEnigmaMachine(NewPlugboard(), rotors, reflector)
+
+	if err := em.SetRotorPositions(strings.Split(positions, "")); err != nil {
+		return nil, err
+	}
+	if err := em.SetRotorRingSettings(strings.Split(ringSettings, "")); err != nil {
+		return nil, err
+	}
+
+	for _, pair := range c.Plugboard.Pairs {
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("plugboard pairs must be two characters long")
+		}
+		pair = strings.ToUpper(pair)
+		if err := em.AddPlugboardConnection(rune(pair[0]), rune(pair[1])); err != nil {
+			return nil, err
+		}
+	}
+
+	return em, nil
+}
+
+func (c Config) rotorPositions() string {
+	if c.RotorPositions == "" {
+		return strings.Repeat("A", len(c.Rotors))
+	}
+	return c.RotorPositions
+}
+
+func (c Config) rotorRingSettings() string {
+	if c.RotorRingSettings == "" {
+		return strings.Repeat("A", len(c.Rotors))
+	}
+	return c.RotorRingSettings
+}
+
+// name returns the reflector selection, a wiring without a name means the
+// rewirable reflector D.
+func (r ReflectorConfig) name() string {
+	if r.Name == "" && len(r.Wiring) > 0 {
+		return "D"
+	}
+	return r.Name
+}
+
+func (r ReflectorConfig) String() string {
+	if len(r.Wiring) > 0 {
+		return fmt.Sprintf("%s %s", r.name(), r.Wiring)
+	}
+	return r.Name
+}
+
+// reflectorConfig has the same fields as ReflectorConfig without its
+// marshaling methods, so they can fall back to the default behaviour.
+type reflectorConfig ReflectorConfig
+
+func (r ReflectorConfig) MarshalJSON() ([]byte, error) {
+	if len(r.Wiring) == 0 {
+		return json.Marshal(r.Name)
+	}
+	return json.Marshal(reflectorConfig(r))
+}
+
+func (r *ReflectorConfig) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*r = ReflectorConfig{}
+		return json.Unmarshal(data, &r.Name)
+	}
+	return json.Unmarshal(data, (*reflectorConfig)(r))
+}
+
+func (r ReflectorConfig) MarshalYAML() (any, error) {
+	if len(r.Wiring) == 0 {
+		return r.Name, nil
+	}
+	return reflectorConfig(r), nil
+}
+
+func (r *ReflectorConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*r = ReflectorConfig{}
+		return node.Decode(&r.Name)
+	}
+	return node.Decode((*reflectorConfig)(r))
+}
+
+// MarshalConfig encodes the config in one of the CONFIG_FORMAT_* formats.
+func MarshalConfig(c Config, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case CONFIG_FORMAT_JSON:
+		return json.MarshalIndent(c, "", "  ")
+	case CONFIG_FORMAT_YAML, "yml":
+		return yaml.Marshal(c)
+	case CONFIG_FORMAT_TOML:
+		// TOML has no hook for a value that is either a string or a table,
+		// so go through the JSON form which already handles the reflector
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		var tree map[string]any
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+		return toml.Marshal(tree)
+	default:
+		return nil, fmt.Errorf("invalid config format: %s", format)
+	}
+}
+
+// UnmarshalConfig decodes a config in one of the CONFIG_FORMAT_* formats.
+func UnmarshalConfig(data []byte, format string) (Config, error) {
+	var c Config
+	switch strings.ToLower(format) {
+	case CONFIG_FORMAT_JSON:
+		err := json.Unmarshal(data, &c)
+		return c, err
+	case CONFIG_FORMAT_YAML, "yml":
+		err := yaml.Unmarshal(data, &c)
+		return c, err
+	case CONFIG_FORMAT_TOML:
+		var tree map[string]any
+		if err := toml.Unmarshal(data, &tree); err != nil {
+			return c, err
+		}
+		data, err := json.Marshal(tree)
+		if err != nil {
+			return c, err
+		}
+		err = json.Unmarshal(data, &c)
+		return c, err
+	default:
+		return c, fmt.Errorf("invalid config format: %s", format)
+	}
+}
@@ -0,0 +1,191 @@
+package enigma
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Build(t *testing.T) {
+	c := DefaultConfig()
+
+	em, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := em.EncryptString("bootdev rocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted != "WLQUC DIFFV VH" {
+		t.Fatalf("expected WLQUC DIFFV VH, got %s", encrypted)
+	}
+}
+
+func TestConfig_Build_Defaults(t *testing.T) {
+	// empty positions and ring settings mean A for every rotor
+	c := Config{
+		Model:     MODEL_M4,
+		Rotors:    []string{"Beta", "II", "IV", "I"},
+		Reflector: ReflectorConfig{Name: "B-thin"},
+	}
+
+	em, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if positions := em.GetRotorPositions(); !reflect.DeepEqual(positions, []int{0, 0, 0, 0}) {
+		t.Fatalf("expected positions [0 0 0 0], got %v", positions)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	d := []string{"AC", "DZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		valid  bool
+	}{
+		{"default", func(c *Config) {}, true},
+		{"rewirable reflector", func(c *Config) { c.Reflector = ReflectorConfig{Wiring: d} }, true},
+		{"no rotors", func(c *Config) { c.Rotors = nil }, false},
+		{"unknown rotor", func(c *Config) { c.Rotors = []string{"III", "II", "IX"} }, false},
+		{"wrong model", func(c *Config) { c.Model = MODEL_M4 }, false},
+		{"short positions", func(c *Config) { c.RotorPositions = "AA" }, false},
+		{"bad position", func(c *Config) { c.RotorPositions = "A1A" }, false},
+		{"long ring settings", func(c *Config) { c.RotorRingSettings = "AAAA" }, false},
+		{"no reflector", func(c *Config) { c.Reflector = ReflectorConfig{} }, false},
+		{"fixed reflector rewired", func(c *Config) { c.Reflector = ReflectorConfig{Name: "B", Wiring: d} }, false},
+		{"bad pair", func(c *Config) { c.Plugboard.Pairs = []string{"ABC"} }, false},
+		{"pair reused", func(c *Config) { c.Plugboard.Pairs = []string{"AB", "BC"} }, false},
+		{"too many pairs", func(c *Config) {
+			c.Plugboard.Pairs = []string{"AB", "CD", "EF", "GH", "IJ", "KL", "MN", "OP", "QR", "ST", "UV"}
+		}, false},
+	}
+
+	for _, test := range tests {
+		c := DefaultConfig()
+		test.modify(&c)
+		err := c.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected no error, got %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		}
+	}
+}
+
+func TestConfig_RoundTrip(t *testing.T) {
+	configs := []Config{
+		{
+			Model:             MODEL_M3,
+			Rotors:            []string{"VI", "II", "VIII"},
+			RotorPositions:    "QEY",
+			RotorRingSettings: "CKT",
+			Reflector:         ReflectorConfig{Name: "C"},
+			Plugboard:         PlugboardConfig{Pairs: []string{"AB", "CD"}},
+		},
+		{
+			Rotors:         []string{"III", "II", "I"},
+			RotorPositions: "AAA",
+			Reflector: ReflectorConfig{
+				Name:   "D",
+				Wiring: []string{"AC", "DZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"},
+			},
+			Plugboard: PlugboardConfig{Pairs: []string{}},
+		},
+	}
+
+	for _, format := range []string{CONFIG_FORMAT_JSON, CONFIG_FORMAT_YAML, CONFIG_FORMAT_TOML} {
+		for _, c := range configs {
+			data, err := MarshalConfig(c, format)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			decoded, err := UnmarshalConfig(data, format)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if !reflect.DeepEqual(decoded, c) {
+				t.Fatalf("%s: expected %+v, got %+v from\n%s", format, c, decoded, data)
+			}
+		}
+	}
+}
+
+func TestUnmarshalConfig_CLISchema(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{CONFIG_FORMAT_YAML, `
+rotors:
+  - III
+  - II
+  - I
+rotor-positions: ABC
+rotor-ring-settings: DEF
+reflector: C
+plugboard:
+  pairs:
+    - AB
+    - CD
+`},
+		{CONFIG_FORMAT_JSON, `{
+  "rotors": ["III", "II", "I"],
+  "rotor-positions": "ABC",
+  "rotor-ring-settings": "DEF",
+  "reflector": "C",
+  "plugboard": {"pairs": ["AB", "CD"]}
+}`},
+		{CONFIG_FORMAT_TOML, `
+rotors = ["III", "II", "I"]
+rotor-positions = "ABC"
+rotor-ring-settings = "DEF"
+reflector = "C"
+
+[plugboard]
+pairs = ["AB", "CD"]
+`},
+	}
+
+	expected := Config{
+		Rotors:            []string{"III", "II", "I"},
+		RotorPositions:    "ABC",
+		RotorRingSettings: "DEF",
+		Reflector:         ReflectorConfig{Name: "C"},
+		Plugboard:         PlugboardConfig{Pairs: []string{"AB", "CD"}},
+	}
+
+	for _, test := range tests {
+		c, err := UnmarshalConfig([]byte(test.data), test.format)
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if !reflect.DeepEqual(c, expected) {
+			t.Fatalf("%s: expected %+v, got %+v", test.format, expected, c)
+		}
+	}
+}
+
+func TestMarshalConfig_ReflectorName(t *testing.T) {
+	data, err := MarshalConfig(DefaultConfig(), CONFIG_FORMAT_YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "reflector: B\n") {
+		t.Fatalf("expected the reflector to be written as its name, got\n%s", data)
+	}
+}
+
+func TestMarshalConfig_InvalidFormat(t *testing.T) {
+	if _, err := MarshalConfig(DefaultConfig(), "xml"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := UnmarshalConfig([]byte("<config/>"), "xml"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
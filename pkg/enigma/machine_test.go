@@ -2,6 +2,7 @@ package enigma
 
 import (
 	"errors"
+	"slices"
 	"testing"
 )
 
@@ -130,6 +131,213 @@ func TestEnigmaMachine_Encrypt_WithPlugboard(t *testing.T) {
 	}
 }
 
+func TestEnigmaMachine_DoubleStep(t *testing.T) {
+	rotor1, _ := CreateRotorI()
+	rotor2, _ := CreateRotorII()
+	rotor3, _ := CreateRotorIII()
+	reflector, _ := CreateReflectorB()
+	em := NewEnigmaMachine(NewPlugboard(), []*Rotor{rotor1, rotor2, rotor3}, reflector)
+
+	if err := em.SetRotorPositions([]string{"A", "D", "U"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// ADU -> ADV -> AEW -> BFX -> BFY
+	expected := [][]int{
+		{0, 3, 21},
+		{0, 4, 22},
+		{1, 5, 23},
+		{1, 5, 24},
+	}
+
+	for _, want := range expected {
+		if _, err := em.encrypt('A'); err != nil {
+			t.Fatal(err)
+		}
+		got := em.GetRotorPositions()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("expected positions %v, got %v", want, got)
+			}
+		}
+	}
+}
+
+func TestEnigmaMachine_DoubleStep_LeftmostNotchIgnored(t *testing.T) {
+	rotor1, _ := CreateRotorI()
+	rotor2, _ := CreateRotorII()
+	rotor3, _ := CreateRotorIII()
+	reflector, _ := CreateReflectorB()
+	em := NewEnigmaMachine(NewPlugboard(), []*Rotor{rotor1, rotor2, rotor3}, reflector)
+
+	// the leftmost rotor sitting on its notch has no rotor to push
+	if err := em.SetRotorPositions([]string{"Q", "A", "A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := em.encrypt('A'); err != nil {
+		t.Fatal(err)
+	}
+
+	got := em.GetRotorPositions()
+	want := []int{16, 0, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected positions %v, got %v", want, got)
+		}
+	}
+}
+
+func TestEnigmaMachine_DualNotchStepping(t *testing.T) {
+	rotor1, _ := CreateRotorI()
+	rotor2, _ := CreateRotorVIII()
+	rotor3, _ := CreateRotorVI()
+	reflector, _ := CreateReflectorB()
+	em := NewEnigmaMachine(NewPlugboard(), []*Rotor{rotor1, rotor2, rotor3}, reflector)
+
+	tests := []struct {
+		start    []string
+		expected [][]int
+	}{
+		// the right rotor turns the middle one over at M
+		{[]string{"A", "A", "L"}, [][]int{{0, 0, 12}, {0, 1, 13}}},
+		// and again at Z
+		{[]string{"A", "A", "Y"}, [][]int{{0, 0, 25}, {0, 1, 0}}},
+		// the middle rotor double steps at both of its notches
+		{[]string{"A", "L", "Z"}, [][]int{{0, 12, 0}, {1, 13, 1}}},
+		{[]string{"A", "Y", "Z"}, [][]int{{0, 25, 0}, {1, 0, 1}}},
+	}
+
+	for _, test := range tests {
+		if err := em.SetRotorPositions(test.start); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range test.expected {
+			if _, err := em.encrypt('A'); err != nil {
+				t.Fatal(err)
+			}
+			got := em.GetRotorPositions()
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("start %v: expected positions %v, got %v", test.start, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestEnigmaMachine_M4_ThinRotorDoesNotStep(t *testing.T) {
+	greek, _ := CreateRotorBeta()
+	rotor1, _ := CreateRotorI()
+	rotor2, _ := CreateRotorII()
+	rotor3, _ := CreateRotorIII()
+	reflector, _ := CreateReflectorBThin()
+	em := NewEnigmaMachine(NewPlugboard(), []*Rotor{greek, rotor1, rotor2, rotor3}, reflector)
+
+	// the leftmost stepping rotor sits on its notch and the middle one is
+	// about to double step, the thin rotor still must not move
+	if err := em.SetRotorPositions([]string{"C", "Q", "D", "V"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]int{
+		{2, 16, 4, 22},
+		{2, 17, 5, 23},
+		{2, 17, 5, 24},
+	}
+
+	for _, want := range expected {
+		if _, err := em.encrypt('A'); err != nil {
+			t.Fatal(err)
+		}
+		got := em.GetRotorPositions()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("expected positions %v, got %v", want, got)
+			}
+		}
+	}
+}
+
+func TestEnigmaMachine_DecryptString_Whitespace(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ciphertext pasted with the line breaks and tabs of a signal form
+	decrypted, err := em.DecryptString("WLQUC\tDI\r\nwlq\v\f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	em.Reset()
+	expected, err := em.DecryptString("WLQUC DIWLQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != expected {
+		t.Fatalf("expected %s, got %s", expected, decrypted)
+	}
+}
+
+func TestEnigmaMachine_DecryptString_Reciprocal(t *testing.T) {
+	message := "the quick brown fox jumps over the lazy dog"
+	expected := "THEQUICKBROWNFOXJUMPSOVERTHELAZYDOG"
+
+	tests := []struct {
+		positions    []string
+		ringSettings []string
+		plugboard    map[rune]rune
+	}{
+		{[]string{"A", "A", "A"}, []string{"A", "A", "A"}, map[rune]rune{}},
+		{[]string{"Q", "E", "V"}, []string{"B", "C", "D"}, map[rune]rune{'A': 'Z', 'K': 'M'}},
+		{[]string{"Z", "Z", "Z"}, []string{"Z", "Z", "Z"}, map[rune]rune{'T': 'H', 'E': 'Q'}},
+	}
+
+	for _, test := range tests {
+		em, err := setupEnigmaMachine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		em.SetRotorRingSettings(test.ringSettings)
+		em.SetPlugboardConnections(test.plugboard)
+
+		em.SetRotorPositions(test.positions)
+		encrypted, err := em.EncryptString(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		em.SetRotorPositions(test.positions)
+		decrypted, err := em.DecryptString(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decrypted != expected {
+			t.Fatalf("positions %v: expected %s, got %s", test.positions, expected, decrypted)
+		}
+	}
+}
+
+func TestRecoverWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ANGRIFFXUMXFUENFUHR", "ANGRIFF UM FUENFUHR"},
+		{"XXKRKRXALLEXX", "KRKR ALLE"},
+		{"KEINE", "KEINE"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if result := RecoverWords(test.input); result != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, result)
+		}
+	}
+}
+
 func TestEnigmaMachine_NormalizeMessage(t *testing.T) {
 	em, err := setupEnigmaMachine()
 	if err != nil {
@@ -209,3 +417,55 @@ func setupEnigmaMachine() (*EnigmaMachine, error) {
 	em := NewEnigmaMachine(plugboard, rotors, reflector)
 	return em, nil
 }
+
+func TestEnigmaMachine_EncryptIndices(t *testing.T) {
+	em, err := Config{
+		Model:             "M4",
+		Rotors:            []string{"Beta", "II", "IV", "I"},
+		RotorPositions:    "VJNA",
+		RotorRingSettings: "AAAV",
+		Reflector:         ReflectorConfig{Name: "B-thin"},
+		Plugboard:         PlugboardConfig{Pairs: []string{"AT", "BL", "DF", "GJ", "HM", "NW", "OP", "QY", "RZ", "VX"}},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := "THEQUICKBROWNFOXJUMPSOVERTHELAZYDOGANDKEEPSONRUNNINGPASTTHEDOUBLESTEP"
+	expected, err := em.DecryptString(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indices := []byte(message)
+	for i := range indices {
+		indices[i] -= 'A'
+	}
+	em.Reset()
+	if err := em.EncryptIndices(indices); err != nil {
+		t.Fatal(err)
+	}
+	for i, index := range indices {
+		if got := rune('A' + index); got != rune(expected[i]) {
+			t.Fatalf("letter %d: expected %c, got %c", i, expected[i], got)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		em.Reset()
+		em.EncryptIndices(indices)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %.0f", allocs)
+	}
+
+	// an invalid index is found before any rotor moves
+	em.Reset()
+	before := em.GetRotorPositions()
+	if err := em.EncryptIndices([]byte{0, 1, 26}); err == nil {
+		t.Error("expected error for index 26, got nil")
+	}
+	if after := em.GetRotorPositions(); !slices.Equal(before, after) {
+		t.Errorf("expected the rotors not to move, went from %v to %v", before, after)
+	}
+}
@@ -0,0 +1,83 @@
+package enigma
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The machine models a selection can be validated against. An empty model
+// picks the three rotor rules or the M4 rules based on the number of rotors.
+const (
+	MODEL_ENIGMA_I = "I"
+	MODEL_M3       = "M3"
+	MODEL_M4       = "M4"
+)
+
+var (
+	enigmaIRotors     = []string{"I", "II", "III", "IV", "V"}
+	navalRotors       = []string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII"}
+	thinRotors        = []string{"Beta", "Gamma"}
+	enigmaIReflectors = []string{"A", "B", "C", "D"}
+	m3Reflectors      = []string{"B", "C"}
+	thinReflectors    = []string{"B-thin", "C-thin"}
+)
+
+// ValidateModelSelection checks that the rotors and reflector could actually
+// be fitted into the given model. Rotors are listed from left to right, so for
+// the M4 the thin rotor comes first.
+func ValidateModelSelection(model string, rotorSelection []string, reflectorSelection string) error {
+	switch model {
+	case "":
+		if len(rotorSelection) == 4 {
+			return ValidateModelSelection(MODEL_M4, rotorSelection, reflectorSelection)
+		}
+		// any of the three rotor parts are accepted together
+		return validateSelection("I/M3", rotorSelection, navalRotors, reflectorSelection, enigmaIReflectors)
+	case MODEL_ENIGMA_I:
+		return validateSelection(model, rotorSelection, enigmaIRotors, reflectorSelection, enigmaIReflectors)
+	case MODEL_M3:
+		return validateSelection(model, rotorSelection, navalRotors, reflectorSelection, m3Reflectors)
+	case MODEL_M4:
+		if len(rotorSelection) != 4 {
+			return fmt.Errorf("model %s needs 4 rotors, got %d", model, len(rotorSelection))
+		}
+		if !slices.Contains(thinRotors, rotorSelection[0]) {
+			return fmt.Errorf("model %s needs a thin rotor (Beta or Gamma) in the leftmost slot, got %s", model, rotorSelection[0])
+		}
+		return validateSelection(model, rotorSelection[1:], navalRotors, reflectorSelection, thinReflectors)
+	default:
+		return fmt.Errorf("invalid model: %s", model)
+	}
+}
+
+func validateSelection(model string, rotorSelection, rotors []string, reflectorSelection string, reflectors []string) error {
+	if len(rotorSelection) != 3 {
+		return fmt.Errorf("model %s needs 3 stepping rotors, got %d", model, len(rotorSelection))
+	}
+	for _, rotor := range rotorSelection {
+		if !slices.Contains(rotors, rotor) {
+			return fmt.Errorf("rotor %s is not available on model %s", rotor, model)
+		}
+	}
+	if !slices.Contains(reflectors, reflectorSelection) {
+		return fmt.Errorf("reflector %s is not available on model %s", reflectorSelection, model)
+	}
+	return nil
+}
+
+// RotorOrders returns every way of putting slots of the given rotors into the
+// machine, from left to right, without using a rotor twice.
+func RotorOrders(rotors []string, slots int) [][]string {
+	if slots == 0 {
+		return [][]string{{}}
+	}
+
+	orders := [][]string{}
+	for i, rotor := range rotors {
+		rest := append(slices.Clone(rotors[:i]), rotors[i+1:]...)
+		for _, order := range RotorOrders(rest, slots-1) {
+			orders = append(orders, append([]string{rotor}, order...))
+		}
+	}
+	return orders
+}
@@ -0,0 +1,64 @@
+package enigma
+
+import "testing"
+
+func TestValidateModelSelection(t *testing.T) {
+	tests := []struct {
+		model     string
+		rotors    []string
+		reflector string
+		valid     bool
+	}{
+		{"", []string{"III", "II", "I"}, "B", true},
+		{"", []string{"VI", "VII", "VIII"}, "A", true},
+		{"", []string{"Beta", "II", "IV", "I"}, "B-thin", true},
+		{"", []string{"III", "II", "I"}, "B-thin", false},
+		{"", []string{"II", "IV", "I", "Beta"}, "B-thin", false},
+		{MODEL_ENIGMA_I, []string{"III", "II", "I"}, "A", true},
+		{MODEL_ENIGMA_I, []string{"VI", "II", "I"}, "B", false},
+		{MODEL_M3, []string{"VI", "II", "I"}, "C", true},
+		{MODEL_M3, []string{"III", "II", "I"}, "A", false},
+		{MODEL_M3, []string{"Beta", "II", "I"}, "B", false},
+		{MODEL_M4, []string{"Gamma", "VIII", "VII", "VI"}, "C-thin", true},
+		{MODEL_M4, []string{"Beta", "II", "I"}, "B-thin", false},
+		{MODEL_M4, []string{"Beta", "Gamma", "II", "I"}, "B-thin", false},
+		{MODEL_M4, []string{"Beta", "III", "II", "I"}, "B", false},
+		{"M5", []string{"III", "II", "I"}, "B", false},
+	}
+
+	for _, test := range tests {
+		err := ValidateModelSelection(test.model, test.rotors, test.reflector)
+		if test.valid && err != nil {
+			t.Errorf("model %q %v %s: expected no error, got %v", test.model, test.rotors, test.reflector, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("model %q %v %s: expected error, got nil", test.model, test.rotors, test.reflector)
+		}
+	}
+}
+
+func TestRotorOrders(t *testing.T) {
+	orders := RotorOrders([]string{"I", "II", "III", "IV", "V"}, 3)
+	if len(orders) != 60 {
+		t.Fatalf("expected 60 orders, got %d", len(orders))
+	}
+
+	seen := map[string]bool{}
+	for _, order := range orders {
+		if len(order) != 3 {
+			t.Fatalf("expected 3 rotors, got %v", order)
+		}
+		if order[0] == order[1] || order[1] == order[2] || order[0] == order[2] {
+			t.Fatalf("rotor used twice in %v", order)
+		}
+		key := order[0] + "," + order[1] + "," + order[2]
+		if seen[key] {
+			t.Fatalf("order %v listed twice", order)
+		}
+		seen[key] = true
+	}
+
+	if orders[0][0] != "I" || orders[0][1] != "II" || orders[0][2] != "III" {
+		t.Fatalf("expected the first order to be [I II III], got %v", orders[0])
+	}
+}
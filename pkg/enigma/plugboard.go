@@ -6,10 +6,22 @@ import (
 
 type Plugboard struct {
 	connections map[rune]rune
+	// table swaps the letter indices the same way as connections
+	table [ALPHABET_SIZE]byte
 }
 
 func NewPlugboard() *Plugboard {
-	return &Plugboard{connections: map[rune]rune{}}
+	p := &Plugboard{}
+	p.clearConnections()
+	return p
+}
+
+// connect plugs a cable between two letters without any checks.
+func (p *Plugboard) connect(a, b rune) {
+	p.connections[a] = b
+	p.connections[b] = a
+	p.table[runeToAlphabetIndex(a)] = byte(runeToAlphabetIndex(b))
+	p.table[runeToAlphabetIndex(b)] = byte(runeToAlphabetIndex(a))
 }
 
 func (p *Plugboard) addConnection(a, b rune) error {
@@ -33,8 +45,7 @@ func (p *Plugboard) addConnection(a, b rune) error {
 		return fmt.Errorf("cannot add more than 10 connections")
 	}
 
-	p.connections[a] = b
-	p.connections[b] = a
+	p.connect(a, b)
 
 	return nil
 }
@@ -48,24 +59,27 @@ func (p *Plugboard) removeConnection(a rune) error {
 		return fmt.Errorf("letter %c is not connected", a)
 	}
 
-	delete(p.connections, p.connections[a])
+	b := p.connections[a]
+	delete(p.connections, b)
 	delete(p.connections, a)
+	p.table[runeToAlphabetIndex(a)] = byte(runeToAlphabetIndex(a))
+	p.table[runeToAlphabetIndex(b)] = byte(runeToAlphabetIndex(b))
 
 	return nil
 }
 
 func (p *Plugboard) clearConnections() {
 	p.connections = map[rune]rune{}
+	for i := range p.table {
+		p.table[i] = byte(i)
+	}
 }
 
 func (p *Plugboard) transform(letter rune) (rune, error) {
 	if letter < 'A' || letter > 'Z' {
 		return 0, fmt.Errorf("invalid letter: %c", letter)
 	}
-	if connection, ok := p.connections[letter]; ok {
-		return connection, nil
-	}
-	return letter, nil
+	return alphabetIndexToRune(int(p.table[runeToAlphabetIndex(letter)])), nil
 }
 
 func (p *Plugboard) countConnections() int {
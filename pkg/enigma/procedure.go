@@ -0,0 +1,288 @@
+package enigma
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The message key procedures a message can be sent with.
+const (
+	// PROCEDURE_1938 sends the message key typed twice and encrypted at the
+	// daily start position, as was done until September 1938.
+	PROCEDURE_1938 = "1938"
+	// PROCEDURE_1940 sends a start position of the operator's choosing in
+	// the clear, with the message key encrypted once at it, as was done from
+	// May 1940.
+	PROCEDURE_1940 = "1940"
+)
+
+// Message is an encrypted message as it was sent: the indicator carrying the
+// encrypted message key, followed by the body.
+type Message struct {
+	Indicator string
+	Body      string
+}
+
+func (m Message) String() string {
+	return fmt.Sprintf("%s %s", m.Indicator, m.Body)
+}
+
+// RandomMessageKey returns n random letters, to be used as a message key. The
+// operators were meant to pick them at random but often did not, which is
+// one of the things that let the keys be broken.
+func RandomMessageKey(n int) (string, error) {
+	var key strings.Builder
+	for i := 0; i < n; i++ {
+		l, err := rand.Int(rand.Reader, big.NewInt(26))
+		if err != nil {
+			return "", err
+		}
+		key.WriteRune(alphabetIndexToRune(int(l.Int64())))
+	}
+	return key.String(), nil
+}
+
+// EncryptWithDoubledKey encrypts a message following the 1938 procedure. The
+// rotors are set to the daily start position, the Grundstellung, which is
+// the position last set with SetRotorPositions, and the message key is typed
+// twice. The rotors are then turned to the message key and the message is
+// encrypted. The machine is left at the daily start position.
+func (e *EnigmaMachine) EncryptWithDoubledKey(messageKey, message string) (Message, error) {
+	key, err := e.messageKey(messageKey)
+	if err != nil {
+		return Message{}, err
+	}
+
+	ground := e.startPositions
+	defer e.restartAt(ground)
+
+	e.Reset()
+	indicator, err := e.transformString(key + key)
+	if err != nil {
+		return Message{}, err
+	}
+
+	if err := e.SetRotorPositions(strings.Split(key, "")); err != nil {
+		return Message{}, err
+	}
+	body, err := e.EncryptString(message)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Indicator: indicator, Body: body}, nil
+}
+
+// DecryptWithDoubledKey decrypts a message sent with the 1938 procedure,
+// returning the message key and the plaintext. The indicator is decrypted at
+// the daily start position, and both halves must agree on the message key.
+func (e *EnigmaMachine) DecryptWithDoubledKey(m Message) (string, string, error) {
+	indicator, err := e.normailizeMessage(m.Indicator)
+	if err != nil {
+		return "", "", err
+	}
+	if len(indicator) != 2*len(e.rotors) {
+		return "", "", fmt.Errorf("indicator %s must be %d letters long", m.Indicator, 2*len(e.rotors))
+	}
+
+	ground := e.startPositions
+	defer e.restartAt(ground)
+
+	e.Reset()
+	doubled, err := e.transformString(indicator)
+	if err != nil {
+		return "", "", err
+	}
+	key := doubled[:len(e.rotors)]
+	if doubled[len(e.rotors):] != key {
+		return "", "", fmt.Errorf("indicator %s decrypts to %s, which does not repeat the message key", m.Indicator, doubled)
+	}
+
+	if err := e.SetRotorPositions(strings.Split(key, "")); err != nil {
+		return "", "", err
+	}
+	plaintext, err := e.DecryptString(m.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// Header is the preamble of a message sent with the 1940 procedure, written
+// as the time, the parts of a long message, the number of letters, and the
+// start position with the encrypted message key, such as
+// "1240 - 2TLE 1TL - 179 - WXC KCH -".
+type Header struct {
+	// Time is when the message was sent, as HHMM.
+	Time string
+	// Parts is left as written, such as "2TLE 1TL" for the first of two
+	// parts, and is empty for a message in one part.
+	Parts   string
+	Letters int
+	// Start is the start position chosen by the operator, sent in the clear.
+	Start string
+	// Key is the message key encrypted at the start position.
+	Key string
+}
+
+func (h Header) String() string {
+	fields := []string{}
+	for _, f := range []string{h.Time, h.Parts, strconv.Itoa(h.Letters), h.Start + " " + h.Key} {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return strings.Join(fields, " - ") + " -"
+}
+
+// ParseMessage splits a message sent with the 1940 procedure into its header
+// and body, such as "1240 - 179 - WXC KCH - EDPUD NRGYS ...". The time and
+// parts may each be left out, and are told apart by their shape.
+func ParseMessage(text string) (Header, string, error) {
+	split := strings.Split(text, "-")
+	if len(split) < 3 {
+		return Header{}, "", fmt.Errorf("invalid message header: expected the letter count and indicator separated by -")
+	}
+	fields := split[:len(split)-1]
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	body := strings.TrimSpace(split[len(split)-1])
+
+	h := Header{}
+	indicator := strings.Fields(strings.ToUpper(fields[len(fields)-1]))
+	if len(indicator) != 2 {
+		return h, "", fmt.Errorf("invalid message header: indicator %q must be the start position and the encrypted key", fields[len(fields)-1])
+	}
+	h.Start, h.Key = indicator[0], indicator[1]
+
+	letters, err := strconv.Atoi(fields[len(fields)-2])
+	if err != nil || letters < 0 {
+		return h, "", fmt.Errorf("invalid message header: bad letter count %q", fields[len(fields)-2])
+	}
+	h.Letters = letters
+
+	// the time is digits and the parts are written as 2TLE 1TL, so either
+	// can be told apart when the other is left out
+	rest := fields[:len(fields)-2]
+	if len(rest) > 0 && strings.Trim(rest[0], "0123456789") == "" {
+		h.Time = rest[0]
+		if len(h.Time) != 4 {
+			return h, "", fmt.Errorf("invalid message header: time %q must be HHMM", h.Time)
+		}
+		rest = rest[1:]
+	}
+	for _, field := range rest {
+		for _, part := range strings.Fields(field) {
+			if !isPart(part) {
+				return h, "", fmt.Errorf("invalid message header: %q is neither a time as HHMM nor parts such as 2TLE 1TL", field)
+			}
+		}
+	}
+	h.Parts = strings.Join(rest, " - ")
+
+	return h, body, nil
+}
+
+// isPart reports whether the word counts the parts of a message, such as
+// 2TLE for two parts, or numbers one, such as 1TL.
+func isPart(word string) bool {
+	suffix := strings.TrimLeft(strings.ToUpper(word), "0123456789")
+	return len(suffix) < len(word) && (suffix == "TL" || suffix == "TLE")
+}
+
+// EncryptWithStartPosition encrypts a message following the 1940 procedure.
+// The rotors are set to the start position, chosen by the operator, and the
+// message key is typed once. The rotors are then turned to the message key
+// and the message is encrypted. The header is filled in except for the time,
+// and the machine is left at the positions it was set to.
+func (e *EnigmaMachine) EncryptWithStartPosition(start, messageKey, message string) (Header, string, error) {
+	position, err := e.messageKey(start)
+	if err != nil {
+		return Header{}, "", fmt.Errorf("start position: %w", err)
+	}
+	key, err := e.messageKey(messageKey)
+	if err != nil {
+		return Header{}, "", err
+	}
+
+	defer e.restartAt(e.startPositions)
+
+	if err := e.SetRotorPositions(strings.Split(position, "")); err != nil {
+		return Header{}, "", err
+	}
+	encryptedKey, err := e.transformString(key)
+	if err != nil {
+		return Header{}, "", err
+	}
+
+	if err := e.SetRotorPositions(strings.Split(key, "")); err != nil {
+		return Header{}, "", err
+	}
+	body, err := e.transformString(message)
+	if err != nil {
+		return Header{}, "", err
+	}
+
+	h := Header{Letters: len(body), Start: position, Key: encryptedKey}
+	return h, e.normailzeOutput(body), nil
+}
+
+// DecryptWithStartPosition decrypts a message sent with the 1940 procedure,
+// returning the message key and the plaintext.
+func (e *EnigmaMachine) DecryptWithStartPosition(h Header, body string) (string, string, error) {
+	position, err := e.messageKey(h.Start)
+	if err != nil {
+		return "", "", fmt.Errorf("start position: %w", err)
+	}
+	encryptedKey, err := e.messageKey(h.Key)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer e.restartAt(e.startPositions)
+
+	if err := e.SetRotorPositions(strings.Split(position, "")); err != nil {
+		return "", "", err
+	}
+	key, err := e.transformString(encryptedKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := e.SetRotorPositions(strings.Split(key, "")); err != nil {
+		return "", "", err
+	}
+	// archive messages are written over several lines
+	plaintext, err := e.DecryptString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// messageKey checks that a message key, or a start position, has a letter
+// for every rotor.
+func (e *EnigmaMachine) messageKey(messageKey string) (string, error) {
+	key, err := e.normailizeMessage(messageKey)
+	if err != nil {
+		return "", err
+	}
+	if len(key) != len(e.rotors) {
+		return "", fmt.Errorf("message key %s must be %d letters long", messageKey, len(e.rotors))
+	}
+	return key, nil
+}
+
+// restartAt sets the rotors and the positions Reset returns to.
+func (e *EnigmaMachine) restartAt(positions []int) {
+	for i, rotor := range e.rotors {
+		rotor.position = positions[i]
+	}
+	e.startPositions = positions
+}
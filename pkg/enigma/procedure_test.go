@@ -0,0 +1,200 @@
+package enigma
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnigmaMachine_EncryptWithDoubledKey(t *testing.T) {
+	config := Config{
+		Rotors:            []string{"II", "I", "III"},
+		RotorPositions:    "KDC",
+		RotorRingSettings: "QRS",
+		Reflector:         ReflectorConfig{Name: "B"},
+		Plugboard:         PlugboardConfig{Pairs: []string{"AV", "BS", "CG"}},
+	}
+	em, err := config.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := em.EncryptWithDoubledKey("pwe", "attack at dawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same as typing the key twice at the daily position, then the
+	// message at the key
+	check, _ := config.Build()
+	indicator, _ := check.DecryptString("PWEPWE")
+	check.SetRotorPositions([]string{"P", "W", "E"})
+	body, _ := check.EncryptString("attack at dawn")
+	if m.Indicator != indicator || m.Body != body {
+		t.Fatalf("expected %s %s, got %s", indicator, body, m)
+	}
+	if positions := em.GetRotorPositions(); !slices.Equal(positions, []int{10, 3, 2}) {
+		t.Errorf("expected the machine back at KDC, got %v", positions)
+	}
+
+	key, plaintext, err := em.DecryptWithDoubledKey(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "PWE" || plaintext != "ATTACKATDAWN" {
+		t.Errorf("expected PWE ATTACKATDAWN, got %s %s", key, plaintext)
+	}
+
+	// a second message uses the same daily position
+	again, err := em.EncryptWithDoubledKey("PWE", "attack at dawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != m {
+		t.Errorf("expected %s, got %s", m, again)
+	}
+}
+
+func TestEnigmaMachine_DecryptWithDoubledKey_Invalid(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := em.EncryptWithDoubledKey("ABC", "HELLO")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	garbled := m
+	garbled.Indicator = m.Indicator[:5] + string('A'+(m.Indicator[5]-'A'+1)%26)
+	tests := []Message{
+		garbled,
+		{Indicator: m.Indicator[:5], Body: m.Body},
+		{Indicator: m.Indicator + "1", Body: m.Body},
+	}
+	for _, test := range tests {
+		if _, _, err := em.DecryptWithDoubledKey(test); err == nil {
+			t.Errorf("%s: expected error, got nil", test)
+		}
+	}
+
+	for _, key := range []string{"AB", "ABCD", "A1C"} {
+		if _, err := em.EncryptWithDoubledKey(key, "HELLO"); err == nil {
+			t.Errorf("%s: expected error, got nil", key)
+		}
+	}
+}
+
+func TestRandomMessageKey(t *testing.T) {
+	for _, n := range []int{3, 4} {
+		key, err := RandomMessageKey(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(key) != n {
+			t.Fatalf("expected %d letters, got %s", n, key)
+		}
+		for _, r := range key {
+			if r < 'A' || r > 'Z' {
+				t.Fatalf("expected letters, got %s", key)
+			}
+		}
+	}
+}
+
+// the first part of a message sent during Operation Barbarossa in 1941,
+// without its Kenngruppe
+const barbarossa = `1240 - 2TLE 1TL - 174 - WXC KCH -
+EDPUD NRGYS ZRCXN UYTPO MRMBO FKTBZ REZKM LXLVE FGUEY SIOZV EQMIK UBPMM YLKLT
+TDEIS MDICA GYKUA CTCDO MOHWX MUUIA UBSTS LRNBZ SZWNR FXWFY SSXJZ VIJHI DISHP
+RKLKA YUPAD TXQSP INQMA TLPIF SVKDA SCTAC DPBOP VHJK`
+
+func TestEnigmaMachine_DecryptWithStartPosition(t *testing.T) {
+	em, err := Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorRingSettings: "BUL",
+		Reflector:         ReflectorConfig{Name: "B"},
+		Plugboard:         PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ", "IN", "KM", "OW", "RX"}},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, body, err := ParseMessage(barbarossa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Header{Time: "1240", Parts: "2TLE 1TL", Letters: 174, Start: "WXC", Key: "KCH"}
+	if h != expected {
+		t.Fatalf("expected header %+v, got %+v", expected, h)
+	}
+
+	key, plaintext, err := em.DecryptWithStartPosition(h, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "BLA" {
+		t.Errorf("expected message key BLA, got %s", key)
+	}
+	if !strings.HasPrefix(plaintext, "AUFKLXABTEILUNGXVONXKURTINOWA") || len(plaintext) != h.Letters {
+		t.Errorf("unexpected plaintext %s", plaintext)
+	}
+
+	// encrypting the plaintext again gives back the archived message
+	h2, body2, err := em.EncryptWithStartPosition("WXC", "BLA", plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2.Time, h2.Parts = h.Time, h.Parts
+	if h2 != h {
+		t.Errorf("expected header %s, got %s", h, h2)
+	}
+	if strings.Join(strings.Fields(body2), "") != strings.Join(strings.Fields(body), "") {
+		t.Errorf("expected body %s, got %s", body, body2)
+	}
+	if h2.String() != "1240 - 2TLE 1TL - 174 - WXC KCH -" {
+		t.Errorf("unexpected header %s", h2)
+	}
+	if positions := em.GetRotorPositions(); !slices.Equal(positions, []int{0, 0, 0}) {
+		t.Errorf("expected the machine back at AAA, got %v", positions)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Header
+		body     string
+		valid    bool
+	}{
+		{"1240 - 174 - WXC KCH - EDPUD", Header{Time: "1240", Letters: 174, Start: "WXC", Key: "KCH"}, "EDPUD", true},
+		{"5 - abc def -", Header{Letters: 5, Start: "ABC", Key: "DEF"}, "", true},
+		{"1240 - 2TLE - 1TL - 179 - WXC KCH - EDPUD", Header{Time: "1240", Parts: "2TLE - 1TL", Letters: 179, Start: "WXC", Key: "KCH"}, "EDPUD", true},
+		{"2TLE 1TL - 179 - WXC KCH - EDPUD", Header{Parts: "2TLE 1TL", Letters: 179, Start: "WXC", Key: "KCH"}, "EDPUD", true},
+		{"2TLE - 179 - WXC KCH - EDPUD", Header{Parts: "2TLE", Letters: 179, Start: "WXC", Key: "KCH"}, "EDPUD", true},
+		{"124 - 179 - WXC KCH - EDPUD", Header{}, "", false},
+		{"1240 - TLE - 179 - WXC KCH - EDPUD", Header{}, "", false},
+		{"2TLE - 1240 - 179 - WXC KCH - EDPUD", Header{}, "", false},
+		{"EDPUD NRGYS", Header{}, "", false},
+		{"1240 - 17A - WXC KCH - EDPUD", Header{}, "", false},
+		{"12:40 - 5 - WXC KCH - EDPUD", Header{}, "", false},
+		{"1240 - 5 - WXCKCH - EDPUD", Header{}, "", false},
+	}
+
+	for _, test := range tests {
+		h, body, err := ParseMessage(test.input)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected error, got nil", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+			continue
+		}
+		if h != test.expected || body != test.body {
+			t.Errorf("%q: expected %+v %q, got %+v %q", test.input, test.expected, test.body, h, body)
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package enigma
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Reflector struct {
+	name   string
 	wiring []rune
+	// table is the wiring as letter indices
+	table [ALPHABET_SIZE]byte
 }
 
 func newReflector(wiring []rune) (*Reflector, error) {
@@ -11,17 +17,89 @@ func newReflector(wiring []rune) (*Reflector, error) {
 		return nil, fmt.Errorf("invalid wiring length: %d", len(wiring))
 	}
 
+	if err := validateReflectorWiring(wiring); err != nil {
+		return nil, err
+	}
+
 	r := &Reflector{
 		wiring: wiring,
 	}
+	for i, w := range wiring {
+		r.table[i] = byte(runeToAlphabetIndex(w))
+	}
 
 	return r, nil
 }
 
+// NewRewirableReflector creates the rewirable reflector UKW-D. The operator
+// plugs 12 pairs of letters given as two letter strings (e.g. "AC"), written
+// in the German notation where B and O are always connected. The fixed BO
+// pair may be listed as well, making 13 pairs.
+func NewRewirableReflector(pairs []string) (*Reflector, error) {
+	wiring := make([]rune, ALPHABET_SIZE)
+	connect := func(a, b rune) error {
+		if a < 'A' || a > 'Z' || b < 'A' || b > 'Z' {
+			return fmt.Errorf("invalid reflector pair: %c%c", a, b)
+		}
+		if a == b {
+			return fmt.Errorf("cannot connect a letter to itself: %c%c", a, b)
+		}
+		for _, l := range []rune{a, b} {
+			if wiring[runeToAlphabetIndex(l)] != 0 {
+				return fmt.Errorf("letter %c is already connected", l)
+			}
+		}
+		wiring[runeToAlphabetIndex(a)] = b
+		wiring[runeToAlphabetIndex(b)] = a
+		return nil
+	}
+
+	for _, pair := range pairs {
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("reflector pairs must be two characters long: %s", pair)
+		}
+		if err := connect(rune(pair[0]), rune(pair[1])); err != nil {
+			return nil, err
+		}
+	}
+
+	fixedA, fixedB := rune(REFLECTOR_D_FIXED_PAIR[0]), rune(REFLECTOR_D_FIXED_PAIR[1])
+	if wiring[runeToAlphabetIndex(fixedA)] != fixedB {
+		if err := connect(fixedA, fixedB); err != nil {
+			return nil, fmt.Errorf("the pair %s is fixed in the UKW-D: %w", REFLECTOR_D_FIXED_PAIR, err)
+		}
+	}
+
+	return newReflector(wiring)
+}
+
+// validateReflectorWiring checks that the wiring connects the letters in
+// pairs: it must be its own inverse and no letter may be wired to itself.
+func validateReflectorWiring(wiring []rune) error {
+	for i, w := range wiring {
+		if w < 'A' || w > 'Z' {
+			return fmt.Errorf("letter %c is not wired", alphabetIndexToRune(i))
+		}
+		if runeToAlphabetIndex(w) == i {
+			return fmt.Errorf("letter %c is wired to itself", w)
+		}
+		if back := wiring[runeToAlphabetIndex(w)]; runeToAlphabetIndex(back) != i {
+			return fmt.Errorf("wiring is not symmetric: %c -> %c -> %c", alphabetIndexToRune(i), w, back)
+		}
+	}
+	return nil
+}
+
+// Name returns the name the reflector was selected by, e.g. "B".
+func (r *Reflector) Name() string {
+	return r.name
+}
+
 func (r *Reflector) transform(letter rune) (rune, error) {
 	if letter < 'A' || letter > 'Z' {
 		return 0, fmt.Errorf("invalid letter: %c", letter)
 	}
 
-	return r.wiring[runeToAlphabetIndex(letter)], nil
+	return alphabetIndexToRune(int(r.table[runeToAlphabetIndex(letter)])), nil
 }
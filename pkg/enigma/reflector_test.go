@@ -50,3 +50,71 @@ func TestNewReflector(t *testing.T) {
 		}
 	}
 }
+
+func TestNewReflector_InvalidWiring(t *testing.T) {
+	tests := []struct {
+		name   string
+		wiring string
+	}{
+		{"too short", "YRUHQ"},
+		{"fixed point", "ARUHQSLDPXNGOKMIEBFZCWVJYT"},
+		{"not symmetric", "BCDEFGHIJKLMNOPQRSTUVWXYZA"},
+	}
+
+	for _, test := range tests {
+		if _, err := newReflector([]rune(test.wiring)); err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		}
+	}
+}
+
+func TestNewRewirableReflector(t *testing.T) {
+	pairs := []string{"AC", "DZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"}
+
+	r, err := NewRewirableReflector(pairs)
+	if err != nil {
+		t.Fatalf("NewRewirableReflector() returned error: %v", err)
+	}
+
+	tests := []struct {
+		input    rune
+		expected rune
+	}{
+		{'A', 'C'},
+		{'C', 'A'},
+		{'Z', 'D'},
+		{'B', 'O'},
+		{'O', 'B'},
+		{'V', 'S'},
+	}
+
+	for _, test := range tests {
+		if result, _ := r.transform(test.input); result != test.expected {
+			t.Errorf("expected %c, got %c", test.expected, result)
+		}
+	}
+
+	// listing the fixed pair is allowed
+	if _, err := NewRewirableReflector(append(pairs, "ob")); err != nil {
+		t.Errorf("NewRewirableReflector() with the fixed pair returned error: %v", err)
+	}
+}
+
+func TestNewRewirableReflector_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		pairs []string
+	}{
+		{"too few pairs", []string{"AC", "DZ", "EW"}},
+		{"letter used twice", []string{"AC", "AZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"}},
+		{"fixed pair broken", []string{"BC", "DZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"}},
+		{"self connection", []string{"AA", "DZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"}},
+		{"bad pair", []string{"ACD", "DZ", "EW", "FM", "GL", "HX", "IU", "JK", "NT", "PQ", "RY", "SV"}},
+	}
+
+	for _, test := range tests {
+		if _, err := NewRewirableReflector(test.pairs); err == nil {
+			t.Errorf("%s: expected error, got nil", test.name)
+		}
+	}
+}
@@ -2,33 +2,79 @@ package enigma
 
 import (
 	"fmt"
-	"slices"
 	"strings"
 )
 
 type Rotor struct {
-	wiring      []rune
-	notch       int
+	name   string
+	wiring []rune
+	// forward and backward are the wiring as letter indices, right to left
+	// and left to right, for every offset of the wiring core from the
+	// contacts, so passing a letter through is a single lookup
+	forward  [ALPHABET_SIZE][ALPHABET_SIZE]byte
+	backward [ALPHABET_SIZE][ALPHABET_SIZE]byte
+	// turnover marks the positions at which a notch is lined up with the pawl
+	turnover    [ALPHABET_SIZE]bool
 	position    int
 	ringSetting int
+	// fixed rotors, like the Zusatzwalze of the M4, are never stepped
+	fixed bool
 }
 
-func NewRotor(wiring []rune, notch rune) (*Rotor, error) {
+// NewRotor creates a rotor with the given wiring and turnover notches.
+// Most rotors have a single notch, the naval rotors VI, VII and VIII have two.
+func NewRotor(wiring []rune, notches ...rune) (*Rotor, error) {
 	if len(wiring) != ALPHABET_SIZE {
 		return nil, fmt.Errorf("invalid wiring length: %d", len(wiring))
 	}
 
-	if notch < 'A' || notch > 'Z' {
-		return nil, fmt.Errorf("invalid notch: %c", notch)
-	}
-
 	r := &Rotor{
 		wiring:      wiring,
-		notch:       runeToAlphabetIndex(notch),
 		position:    0,
 		ringSetting: 0,
 	}
 
+	seen := [ALPHABET_SIZE]bool{}
+	for _, w := range wiring {
+		if w < 'A' || w > 'Z' || seen[runeToAlphabetIndex(w)] {
+			return nil, fmt.Errorf("invalid wiring: %s", string(wiring))
+		}
+		seen[runeToAlphabetIndex(w)] = true
+	}
+
+	// the contact a letter enters on is moved along by the offset, and the
+	// letter leaving is moved back by it
+	for offset := 0; offset < ALPHABET_SIZE; offset++ {
+		for i := 0; i < ALPHABET_SIZE; i++ {
+			out := (runeToAlphabetIndex(wiring[(i+offset)%ALPHABET_SIZE]) - offset + ALPHABET_SIZE) % ALPHABET_SIZE
+			r.forward[offset][i] = byte(out)
+			r.backward[offset][out] = byte(i)
+		}
+	}
+
+	for _, notch := range notches {
+		if notch < 'A' || notch > 'Z' {
+			return nil, fmt.Errorf("invalid notch: %c", notch)
+		}
+		index := runeToAlphabetIndex(notch)
+		if r.turnover[index] {
+			return nil, fmt.Errorf("duplicate notch: %c", notch)
+		}
+		r.turnover[index] = true
+	}
+
+	return r, nil
+}
+
+// NewThinRotor creates a thin, non-stepping rotor (Zusatzwalze) as used in
+// the fourth slot of the M4. It can be set to any position and ring setting
+// by hand but is never moved by the pawls.
+func NewThinRotor(wiring []rune) (*Rotor, error) {
+	r, err := NewRotor(wiring)
+	if err != nil {
+		return nil, err
+	}
+	r.fixed = true
 	return r, nil
 }
 
@@ -50,11 +96,16 @@ func (r *Rotor) setPosition(letter string) error {
 	return nil
 }
 
-// rotate returns true if the rotor should rotate the next rotor
-func (r *Rotor) rotate() bool {
-	rotateNext := r.position == r.notch
-	r.position = (r.position + 1) % ALPHABET_SIZE
-	return rotateNext
+// Name returns the name the rotor was selected by, e.g. "III". Rotors built
+// directly from a wiring have no name.
+func (r *Rotor) Name() string {
+	return r.name
+}
+
+// atNotch reports whether the rotor's notch is lined up with the pawl to its
+// left, meaning the next key press will step the rotor to its left.
+func (r *Rotor) atNotch() bool {
+	return r.turnover[r.position]
 }
 
 // transformForward transforms a letter through the rotor from right to left
@@ -62,17 +113,7 @@ func (r *Rotor) transformForward(letter rune) (rune, error) {
 	if letter < 'A' || letter > 'Z' {
 		return 0, fmt.Errorf("invalid letter: %c", letter)
 	}
-
-	// find the index of the letter in the wiring based on the position
-	index := (runeToAlphabetIndex(letter) + r.position - r.ringSetting + ALPHABET_SIZE) % ALPHABET_SIZE
-	// find the connection in the wiring based on the index
-	transformed := r.wiring[index]
-	// find the index of the transformed letter in the alphabet
-	finalIndex := (runeToAlphabetIndex(transformed) - r.position + r.ringSetting + ALPHABET_SIZE) % ALPHABET_SIZE
-	// find the letter in the alphabet based on the final index
-	transformed = alphabetIndexToRune(finalIndex)
-
-	return transformed, nil
+	return alphabetIndexToRune(int(r.forwardIndex(byte(runeToAlphabetIndex(letter))))), nil
 }
 
 // transformBackward transforms a letter through the rotor from left to right
@@ -80,19 +121,23 @@ func (r *Rotor) transformBackward(letter rune) (rune, error) {
 	if letter < 'A' || letter > 'Z' {
 		return 0, fmt.Errorf("invalid letter: %c", letter)
 	}
+	return alphabetIndexToRune(int(r.backwardIndex(byte(runeToAlphabetIndex(letter))))), nil
+}
 
-	indexOfIncomingInAlphabet := runeToAlphabetIndex(letter)
-	realIndex := (indexOfIncomingInAlphabet + r.position - r.ringSetting + ALPHABET_SIZE) % ALPHABET_SIZE
-	realLetter := alphabetIndexToRune(realIndex)
+// offset is how far the wiring core is turned from the contacts: along by
+// the position and back by the ring setting.
+func (r *Rotor) offset() uint {
+	return uint(r.position-r.ringSetting+ALPHABET_SIZE) % ALPHABET_SIZE
+}
 
-	index := slices.Index(r.wiring, realLetter)
-	if index == -1 {
-		return 0, fmt.Errorf("invalid letter: %c", letter)
-	}
+// forwardIndex passes a letter index through the rotor from right to left.
+func (r *Rotor) forwardIndex(i byte) byte {
+	return r.forward[r.offset()][i%ALPHABET_SIZE]
+}
 
-	transformedIndex := (index - r.position + r.ringSetting + ALPHABET_SIZE) % ALPHABET_SIZE
-	transformed := alphabetIndexToRune(transformedIndex)
-	return transformed, nil
+// backwardIndex passes a letter index through the rotor from left to right.
+func (r *Rotor) backwardIndex(i byte) byte {
+	return r.backward[r.offset()][i%ALPHABET_SIZE]
 }
 
 func (r *Rotor) setRingSetting(letter string) error {
@@ -1,6 +1,9 @@
 package enigma
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestNewRotor(t *testing.T) {
 	// Test rotor I
@@ -12,8 +15,8 @@ func TestNewRotor(t *testing.T) {
 		t.Errorf("NewRotor() returned error: %v", err)
 	}
 
-	if r.notch != 16 {
-		t.Errorf("expected notch 16, got %d", r.notch)
+	if notches := turnoverPositions(r); !slices.Equal(notches, []int{16}) {
+		t.Errorf("expected notches [16], got %v", notches)
 	}
 
 	if r.position != 0 {
@@ -135,43 +138,101 @@ func TestNewRotor_InvalidNotch(t *testing.T) {
 	}
 }
 
-func TestRotor_Rotate(t *testing.T) {
-	r, _ := NewRotor([]rune(ROTOR_I_WIRING), ROTOR_I_NOTCH)
+func TestNewRotor_DuplicateNotch(t *testing.T) {
+	_, err := NewRotor([]rune(ROTOR_VI_WIRING), 'Z', 'Z')
+	if err == nil {
+		t.Error("NewRotor() did not return error for duplicate notch")
+	}
+}
 
-	if r.position != 0 {
-		t.Errorf("expected position 0, got %d", r.position)
+func TestRotor_AtNotch_DualNotch(t *testing.T) {
+	r, err := CreateRotorVI()
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	rotateNext := r.rotate()
-	if r.position != 1 {
-		t.Errorf("expected position 1, got %d", r.position)
+	tests := []struct {
+		position string
+		atNotch  bool
+	}{
+		{"L", false},
+		{"M", true},
+		{"N", false},
+		{"Y", false},
+		{"Z", true},
+		{"A", false},
 	}
-	if rotateNext {
-		t.Error("expected rotateNext to be false")
+
+	for _, test := range tests {
+		r.setPosition(test.position)
+		if atNotch := r.atNotch(); atNotch != test.atNotch {
+			t.Errorf("position %s: expected atNotch %t, got %t", test.position, test.atNotch, atNotch)
+		}
+	}
+}
+
+func TestCreateRotorFromSelection_Naval(t *testing.T) {
+	tests := []struct {
+		selection string
+		wiring    string
+	}{
+		{"VI", ROTOR_VI_WIRING},
+		{"VII", ROTOR_VII_WIRING},
+		{"VIII", ROTOR_VIII_WIRING},
 	}
 
-	rotateNext = r.rotate()
-	if r.position != 2 {
-		t.Errorf("expected position 2, got %d", r.position)
+	for _, test := range tests {
+		r, err := CreateRotorFromSelection(test.selection)
+		if err != nil {
+			t.Fatalf("CreateRotorFromSelection(%s) returned error: %v", test.selection, err)
+		}
+		if string(r.wiring) != test.wiring {
+			t.Errorf("rotor %s: expected wiring %s, got %s", test.selection, test.wiring, string(r.wiring))
+		}
+		if notches := turnoverPositions(r); !slices.Equal(notches, []int{12, 25}) {
+			t.Errorf("rotor %s: expected notches [12 25], got %v", test.selection, notches)
+		}
 	}
-	if rotateNext {
-		t.Error("expected rotateNext to be false")
+}
+
+func TestRotor_AtNotch(t *testing.T) {
+	r, _ := NewRotor([]rune(ROTOR_I_WIRING), ROTOR_I_NOTCH)
+
+	tests := []struct {
+		position string
+		atNotch  bool
+	}{
+		{"A", false},
+		{"P", false},
+		{"Q", true},
+		{"R", false},
+		{"Z", false},
 	}
 
-	r.setPosition("Z")
-	r.rotate()
-	if r.position != 0 {
-		t.Errorf("expected position 0, got %d", r.position)
+	for _, test := range tests {
+		r.setPosition(test.position)
+		if atNotch := r.atNotch(); atNotch != test.atNotch {
+			t.Errorf("position %s: expected atNotch %t, got %t", test.position, test.atNotch, atNotch)
+		}
 	}
 
+	// the ring setting moves the wiring, not the notch
+	r.setRingSetting("F")
 	r.setPosition("Q")
-	rotateNext = r.rotate()
-	if r.position != 17 {
-		t.Errorf("expected position 17, got %d", r.position)
+	if !r.atNotch() {
+		t.Error("expected the notch at Q whatever the ring setting")
 	}
-	if !rotateNext {
-		t.Error("expected rotateNext to be true")
+}
+
+// turnoverPositions returns the positions at which the rotor is at a notch.
+func turnoverPositions(r *Rotor) []int {
+	positions := []int{}
+	for i, at := range r.turnover {
+		if at {
+			positions = append(positions, i)
+		}
 	}
+	return positions
 }
 
 func TestRotorsPathForward(t *testing.T) {
@@ -250,7 +311,7 @@ func TestRotorsPathBackward(t *testing.T) {
 	}
 }
 
-func TestTransformForwardWithRotate(t *testing.T) {
+func TestTransformForwardWithStepping(t *testing.T) {
 	rotor1, _ := NewRotor([]rune(ROTOR_I_WIRING), ROTOR_I_NOTCH)
 	rotor2, _ := NewRotor([]rune(ROTOR_II_WIRING), ROTOR_II_NOTCH)
 
@@ -270,7 +331,7 @@ func TestTransformForwardWithRotate(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		rotors[0].rotate()
+		rotors[0].position = (rotors[0].position + 1) % ALPHABET_SIZE
 		output, _ := rotors[0].transformForward(test.input)
 		if output != test.expectedFromRotor1 {
 			t.Errorf("expected %c, got %c", test.expectedFromRotor1, output)
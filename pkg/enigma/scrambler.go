@@ -0,0 +1,167 @@
+package enigma
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+)
+
+// Scrambler is the rotors and reflector of a machine, without the plugboard,
+// with the permutation they make at every rotor position worked out in
+// advance. For a fixed rotor order, ring setting and reflector the scrambler
+// is just one permutation for each of the 17,576 positions, or 456,976 for the
+// M4, so a message can be encrypted from any start position with a lookup
+// and the plugboard for each letter. Attacks that try many plugboards or
+// start positions on the same rotor order use it instead of the machine.
+//
+// Positions are numbered with the leftmost rotor as the most significant
+// digit, so AAA is 0, AAB is 1 and ZZZ is 17575.
+type Scrambler struct {
+	slots int
+	// perms is the permutation with the rotors standing at each position
+	perms [][ALPHABET_SIZE]byte
+	// next is the position the rotors step to from each position, which
+	// depends only on the positions and notches, not on the ring settings
+	next []int32
+}
+
+// NewScrambler works out the permutations of the rotor order, ring settings
+// and reflector of the config. The rotor positions and plugboard are ignored.
+func NewScrambler(c Config) (*Scrambler, error) {
+	c.RotorPositions = ""
+	c.Plugboard = PlugboardConfig{}
+	em, err := c.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scrambler{slots: len(em.rotors)}
+	n := util.Pow26(s.slots)
+	s.perms = make([][ALPHABET_SIZE]byte, n)
+	s.next = make([]int32, n)
+	for position := 0; position < n; position++ {
+		s.setRotors(em, position)
+		for i := range s.perms[position] {
+			s.perms[position][i] = em.scrambleIndex(byte(i))
+		}
+		em.stepRotors()
+		s.next[position] = int32(s.position(em))
+	}
+	return s, nil
+}
+
+// setRotors turns the rotors of the machine to a position number.
+func (s *Scrambler) setRotors(em *EnigmaMachine, position int) {
+	for i := s.slots - 1; i >= 0; i-- {
+		em.rotors[i].position = position % ALPHABET_SIZE
+		position /= ALPHABET_SIZE
+	}
+}
+
+// position returns the position number of the machine's rotors.
+func (s *Scrambler) position(em *EnigmaMachine) int {
+	position := 0
+	for _, rotor := range em.rotors {
+		position = position*ALPHABET_SIZE + rotor.position
+	}
+	return position
+}
+
+// Positions returns the number of rotor positions.
+func (s *Scrambler) Positions() int {
+	return len(s.perms)
+}
+
+// Position returns the number of a rotor position given as letters, such as
+// "BLE".
+func (s *Scrambler) Position(letters string) (int, error) {
+	letters = strings.ToUpper(letters)
+	if len(letters) != s.slots {
+		return 0, fmt.Errorf("invalid number of rotor positions: %d", len(letters))
+	}
+	position := 0
+	for _, l := range letters {
+		if l < 'A' || l > 'Z' {
+			return 0, fmt.Errorf("invalid letter: %c", l)
+		}
+		position = position*ALPHABET_SIZE + runeToAlphabetIndex(l)
+	}
+	return position, nil
+}
+
+// PositionLetters returns the letters of a position number.
+func (s *Scrambler) PositionLetters(position int) string {
+	letters := make([]byte, s.slots)
+	for i := s.slots - 1; i >= 0; i-- {
+		letters[i] = byte(alphabetIndexToRune(position % ALPHABET_SIZE))
+		position /= ALPHABET_SIZE
+	}
+	return string(letters)
+}
+
+// Step returns the position the rotors move to from a position when a key
+// is pressed.
+func (s *Scrambler) Step(position int) int {
+	return int(s.next[position])
+}
+
+// Permutation returns the permutation of letter indices with the rotors
+// standing at a position. Its entries must not be changed.
+func (s *Scrambler) Permutation(position int) *[ALPHABET_SIZE]byte {
+	return &s.perms[position]
+}
+
+// Rows fills rows with the permutations the letters of a message go through
+// from a start position, one for each key press, and returns the position
+// the rotors end at.
+func (s *Scrambler) Rows(rows [][ALPHABET_SIZE]byte, start int) int {
+	position := start
+	for i := range rows {
+		position = int(s.next[position])
+		rows[i] = s.perms[position]
+	}
+	return position
+}
+
+// EncryptIndices encrypts letter indices in place from a start position, the
+// same as a machine with the plugboard given as a table of letter indices,
+// or none when it is nil. It returns the position the rotors end at. Like
+// the machine, it does not allocate.
+func (s *Scrambler) EncryptIndices(indices []byte, start int, plugboard *[ALPHABET_SIZE]byte) (int, error) {
+	for _, i := range indices {
+		if i >= ALPHABET_SIZE {
+			return start, fmt.Errorf("invalid letter index: %d", i)
+		}
+	}
+
+	position := start
+	if plugboard == nil {
+		for n, i := range indices {
+			position = int(s.next[position])
+			indices[n] = s.perms[position][i]
+		}
+		return position, nil
+	}
+	for n, i := range indices {
+		position = int(s.next[position])
+		indices[n] = plugboard[s.perms[position][plugboard[i]]]
+	}
+	return position, nil
+}
+
+// PlugboardTable returns the plugboard pairs as a table of letter indices,
+// for use with the scrambler.
+func PlugboardTable(pairs []string) ([ALPHABET_SIZE]byte, error) {
+	p := NewPlugboard()
+	for _, pair := range pairs {
+		if len(pair) != 2 {
+			return p.table, fmt.Errorf("plugboard pairs must be two characters long")
+		}
+		pair = strings.ToUpper(pair)
+		if err := p.addConnection(rune(pair[0]), rune(pair[1])); err != nil {
+			return p.table, err
+		}
+	}
+	return p.table, nil
+}
@@ -0,0 +1,166 @@
+package enigma
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrambler_MatchesMachine(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{
+			name: "double step",
+			config: Config{
+				Rotors:            []string{"II", "IV", "V"},
+				RotorPositions:    "BDY",
+				RotorRingSettings: "XAN",
+				Reflector:         ReflectorConfig{Name: "B"},
+				Plugboard:         PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ", "IN", "KM", "OW", "RX"}},
+			},
+		},
+		{
+			name: "dual notch",
+			config: Config{
+				Rotors:            []string{"VI", "VIII", "I"},
+				RotorPositions:    "ZMP",
+				RotorRingSettings: "CQE",
+				Reflector:         ReflectorConfig{Name: "C"},
+			},
+		},
+		{
+			name: "M4",
+			config: Config{
+				Model:             MODEL_M4,
+				Rotors:            []string{"Beta", "II", "IV", "I"},
+				RotorPositions:    "VJNA",
+				RotorRingSettings: "AAAV",
+				Reflector:         ReflectorConfig{Name: "B-thin"},
+				Plugboard:         PlugboardConfig{Pairs: []string{"AT", "BL", "DF", "GJ", "HM", "NW", "OP", "QY", "RZ", "VX"}},
+			},
+		},
+	}
+
+	message := strings.Repeat("WETTERVORHERSAGEBISKAYA", 40)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			em, err := tt.config.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := em.DecryptString(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := NewScrambler(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start, err := s.Position(tt.config.RotorPositions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plugboard, err := PlugboardTable(tt.config.Plugboard.Pairs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			indices, err := normalizeIndices(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end, err := s.EncryptIndices(indices, start, &plugboard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, len(indices))
+			for i, index := range indices {
+				got[i] = byte(alphabetIndexToRune(int(index)))
+			}
+			if string(got) != want {
+				t.Errorf("EncryptIndices() = %s, want %s", got, want)
+			}
+
+			positions := ""
+			for _, p := range em.GetRotorPositions() {
+				positions += string(alphabetIndexToRune(p))
+			}
+			if got := s.PositionLetters(end); got != positions {
+				t.Errorf("end position = %s, want %s", got, positions)
+			}
+		})
+	}
+}
+
+func TestScrambler_Rows(t *testing.T) {
+	config := Config{
+		Rotors:            []string{"I", "II", "III"},
+		RotorRingSettings: "BBB",
+		Reflector:         ReflectorConfig{Name: "B"},
+	}
+	s, err := NewScrambler(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Positions(); got != 17576 {
+		t.Fatalf("Positions() = %d, want 17576", got)
+	}
+
+	start, err := s.Position("adu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make([][ALPHABET_SIZE]byte, 5)
+	end := s.Rows(rows, start)
+	if got := s.PositionLetters(end); got != "BFZ" {
+		t.Errorf("Rows() ended at %s, want BFZ", got)
+	}
+
+	config.RotorPositions = "ADU"
+	em, err := config.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		for x := 0; x < ALPHABET_SIZE; x++ {
+			em.SetRotorPositions([]string{"A", "D", "U"})
+			for j := 0; j < i; j++ {
+				em.encryptIndex(0)
+			}
+			if got := em.encryptIndex(byte(x)); row[x] != got {
+				t.Fatalf("row %d maps %d to %d, want %d", i, x, row[x], got)
+			}
+		}
+		// every row is a reciprocal permutation without fixed points
+		for x, y := range row {
+			if int(y) == x || int(row[y]) != x {
+				t.Fatalf("row %d is not a reciprocal permutation: %v", i, row)
+			}
+		}
+	}
+}
+
+func TestScrambler_Invalid(t *testing.T) {
+	s, err := NewScrambler(Config{Rotors: []string{"I", "II", "III"}, Reflector: ReflectorConfig{Name: "B"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, positions := range []string{"AB", "ABCD", "A1C"} {
+		if _, err := s.Position(positions); err == nil {
+			t.Errorf("Position(%q) expected an error", positions)
+		}
+	}
+	if _, err := s.EncryptIndices([]byte{0, 26}, 0, nil); err == nil {
+		t.Error("EncryptIndices() expected an error for an invalid index")
+	}
+	for _, pairs := range [][]string{{"AB", "BC"}, {"A"}} {
+		if _, err := PlugboardTable(pairs); err == nil {
+			t.Errorf("PlugboardTable(%v) expected an error", pairs)
+		}
+	}
+	if _, err := NewScrambler(Config{Rotors: []string{"I", "II", "IX"}, Reflector: ReflectorConfig{Name: "B"}}); err == nil {
+		t.Error("NewScrambler() expected an error for an unknown rotor")
+	}
+}
@@ -0,0 +1,76 @@
+package enigma
+
+import (
+	"fmt"
+	"maps"
+)
+
+// State is a copy of a machine's setup: the rotor order with each rotor's
+// position and ring setting, the reflector and the plugboard. It is not
+// affected by changes to the machine it was taken from, so it can be restored
+// any number of times.
+type State struct {
+	rotors         []Rotor
+	reflector      Reflector
+	plugboard      map[rune]rune
+	startPositions []int
+}
+
+// Snapshot returns the current state of the machine.
+func (e *EnigmaMachine) Snapshot() State {
+	s := State{
+		rotors:         make([]Rotor, len(e.rotors)),
+		reflector:      *e.reflector,
+		plugboard:      maps.Clone(e.plugboard.connections),
+		startPositions: append([]int(nil), e.startPositions...),
+	}
+	for i, rotor := range e.rotors {
+		s.rotors[i] = *rotor
+	}
+	return s
+}
+
+// Restore puts the machine back into a state taken with Snapshot, including
+// the rotor order and the reflector.
+func (e *EnigmaMachine) Restore(s State) error {
+	if len(s.rotors) == 0 {
+		return fmt.Errorf("invalid state: no rotors")
+	}
+	e.restore(s)
+	return nil
+}
+
+func (e *EnigmaMachine) restore(s State) {
+	// the wirings are never changed, so the copies can share them
+	rotors := make([]*Rotor, len(s.rotors))
+	for i := range s.rotors {
+		rotor := s.rotors[i]
+		rotors[i] = &rotor
+	}
+	reflector := s.reflector
+
+	e.rotors = rotors
+	e.reflector = &reflector
+	e.plugboard = NewPlugboard()
+	for a, b := range s.plugboard {
+		e.plugboard.connect(a, b)
+	}
+	e.startPositions = append([]int(nil), s.startPositions...)
+}
+
+// Clone returns an independent copy of the machine in its current state. It
+// copies the snapshot without the checks of Restore, which only reject states
+// not taken from a machine, so it copies any machine as it is.
+func (e *EnigmaMachine) Clone() *EnigmaMachine {
+	c := &EnigmaMachine{}
+	c.restore(e.Snapshot())
+	return c
+}
+
+// Reset turns the rotors back to the positions they were last set to with
+// SetRotorPositions, undoing the stepping done by encrypting.
+func (e *EnigmaMachine) Reset() {
+	for i, rotor := range e.rotors {
+		rotor.position = e.startPositions[i]
+	}
+}
@@ -0,0 +1,135 @@
+package enigma
+
+import "testing"
+
+func TestEnigmaMachine_Reset(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := em.SetRotorPositions([]string{"Q", "E", "V"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := em.EncryptString("bootdev rocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	em.Reset()
+	positions := em.GetRotorPositions()
+	if positions[0] != 16 || positions[1] != 4 || positions[2] != 21 {
+		t.Fatalf("expected positions [16 4 21], got %v", positions)
+	}
+
+	second, err := em.EncryptString("bootdev rocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("expected %s after reset, got %s", first, second)
+	}
+}
+
+func TestEnigmaMachine_SnapshotRestore(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	em.SetRotorPositions([]string{"D", "F", "U"})
+	em.SetRotorRingSettings([]string{"B", "C", "D"})
+	em.SetPlugboardConnections(map[rune]rune{'A': 'Z', 'Q': 'W'})
+
+	state := em.Snapshot()
+	expected, err := em.EncryptString("the quick brown fox jumps over the lazy dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// change everything covered by the snapshot
+	rotorI, _ := CreateRotorI()
+	rotorIV, _ := CreateRotorIV()
+	rotorV, _ := CreateRotorV()
+	reflectorC, _ := CreateReflectorC()
+	em.rotors = []*Rotor{rotorV, rotorIV, rotorI}
+	em.reflector = reflectorC
+	em.ClearPlugboardConnections()
+	em.AddPlugboardConnection('K', 'L')
+
+	for i := 0; i < 2; i++ {
+		if err := em.Restore(state); err != nil {
+			t.Fatal(err)
+		}
+		encrypted, err := em.EncryptString("the quick brown fox jumps over the lazy dog")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted != expected {
+			t.Fatalf("restore %d: expected %s, got %s", i, expected, encrypted)
+		}
+	}
+}
+
+func TestEnigmaMachine_Restore_EmptyState(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := em.Restore(State{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestEnigmaMachine_Clone(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	em.SetRotorPositions([]string{"A", "D", "U"})
+	em.AddPlugboardConnection('B', 'X')
+
+	clone := em.Clone()
+
+	original, err := em.EncryptString("wetterbericht")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the clone starts where the original was and is not moved by it
+	cloned, err := clone.EncryptString("wetterbericht")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloned != original {
+		t.Fatalf("expected %s, got %s", original, cloned)
+	}
+
+	clone.AddPlugboardConnection('C', 'Y')
+	if _, ok := em.GetPlugboardConnections()['C']; ok {
+		t.Fatal("changing the clone's plugboard changed the original")
+	}
+
+	clone.Reset()
+	em.Reset()
+	if clone.GetRotorPositions()[2] != em.GetRotorPositions()[2] {
+		t.Fatal("expected the clone to reset to the same positions")
+	}
+}
+
+func TestEnigmaMachine_CloneWithoutRotors(t *testing.T) {
+	reflector, err := CreateReflectorB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	em := NewEnigmaMachine(NewPlugboard(), nil, reflector)
+
+	// Restore rejects the snapshot, but Clone still copies the machine as
+	// it is rather than returning a half built one
+	if err := (&EnigmaMachine{}).Restore(em.Snapshot()); err == nil {
+		t.Fatal("expected error restoring a state without rotors, got nil")
+	}
+	clone := em.Clone()
+	if len(clone.rotors) != 0 || clone.reflector == nil || clone.plugboard == nil {
+		t.Fatalf("expected a copy without rotors, got %+v", clone)
+	}
+}
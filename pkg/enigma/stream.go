@@ -0,0 +1,156 @@
+package enigma
+
+import (
+	"fmt"
+	"io"
+)
+
+// streamEncrypter pushes letters through a machine and groups the output in
+// fives, remembering how many letters it has written so the groups carry on
+// across chunk boundaries.
+type streamEncrypter struct {
+	machine *EnigmaMachine
+	letters int
+	// format is FORMAT_GROUPS unless changed with SetFormat
+	format string
+}
+
+// setFormat checks and sets the output format.
+func (s *streamEncrypter) setFormat(format string) error {
+	switch format {
+	case FORMAT_GROUPS, FORMAT_LETTERS, FORMAT_PRESERVE:
+		s.format = format
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// appendEncrypted encrypts the letters in src and appends them to dst.
+// Whitespace is skipped and lower case letters are accepted. It stops at the
+// first invalid character and returns the bytes of src consumed before it.
+// In FORMAT_PRESERVE every byte that is not a letter is copied instead,
+// which leaves multi-byte characters whole even when split across chunks.
+func (s *streamEncrypter) appendEncrypted(dst, src []byte) ([]byte, int, error) {
+	if s.format == FORMAT_PRESERVE {
+		for _, c := range src {
+			switch {
+			case c >= 'A' && c <= 'Z':
+				c = 'A' + s.machine.encryptIndex(c-'A')
+			case c >= 'a' && c <= 'z':
+				c = 'a' + s.machine.encryptIndex(c-'a')
+			}
+			dst = append(dst, c)
+		}
+		return dst, len(src), nil
+	}
+
+	for i, c := range src {
+		if isSpace(rune(c)) {
+			continue
+		}
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			return dst, i, fmt.Errorf("invalid letter: %c", rune(c))
+		}
+
+		encrypted, err := s.machine.encrypt(rune(c))
+		if err != nil {
+			return dst, i, err
+		}
+		if s.format != FORMAT_LETTERS && s.letters > 0 && s.letters%5 == 0 {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, byte(encrypted))
+		s.letters++
+	}
+	return dst, len(src), nil
+}
+
+// EncryptingWriter encrypts everything written to it and writes the
+// ciphertext, in groups of five letters, to the underlying writer. The machine
+// keeps its state between writes, so a message can be written in any number of
+// chunks and gives the same result as EncryptString.
+type EncryptingWriter struct {
+	w   io.Writer
+	enc streamEncrypter
+	buf []byte
+}
+
+func NewEncryptingWriter(w io.Writer, m *EnigmaMachine) *EncryptingWriter {
+	return &EncryptingWriter{
+		w:   w,
+		enc: streamEncrypter{machine: m, format: FORMAT_GROUPS},
+	}
+}
+
+// SetFormat changes the format the ciphertext is written in, one of
+// FORMAT_GROUPS, FORMAT_LETTERS or FORMAT_PRESERVE. It should be called
+// before the first write.
+func (ew *EncryptingWriter) SetFormat(format string) error {
+	return ew.enc.setFormat(format)
+}
+
+// Write encrypts p. Whitespace is skipped, any other character that is not a
+// letter stops the write with an error. The count is the plaintext encrypted,
+// also when the underlying writer fails: the machine has stepped past those
+// letters, so writing them again would encrypt them with the wrong key.
+func (ew *EncryptingWriter) Write(p []byte) (int, error) {
+	var err error
+	var n int
+	ew.buf, n, err = ew.enc.appendEncrypted(ew.buf[:0], p)
+	if _, werr := ew.w.Write(ew.buf); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
+
+// EncryptingReader reads plaintext from the underlying reader and returns the
+// ciphertext in groups of five letters.
+type EncryptingReader struct {
+	r   io.Reader
+	enc streamEncrypter
+	in  []byte
+	out []byte
+	off int
+	err error
+}
+
+func NewEncryptingReader(r io.Reader, m *EnigmaMachine) *EncryptingReader {
+	return &EncryptingReader{
+		r:   r,
+		enc: streamEncrypter{machine: m, format: FORMAT_GROUPS},
+		in:  make([]byte, 4096),
+	}
+}
+
+// SetFormat changes the format the ciphertext is returned in, one of
+// FORMAT_GROUPS, FORMAT_LETTERS or FORMAT_PRESERVE. It should be called
+// before the first read.
+func (er *EncryptingReader) SetFormat(format string) error {
+	return er.enc.setFormat(format)
+}
+
+func (er *EncryptingReader) Read(p []byte) (int, error) {
+	for er.off == len(er.out) {
+		if er.err != nil {
+			return 0, er.err
+		}
+
+		n, err := er.r.Read(er.in)
+		var encErr error
+		er.out, _, encErr = er.enc.appendEncrypted(er.out[:0], er.in[:n])
+		er.off = 0
+		if encErr != nil {
+			er.err = encErr
+		} else if err != nil {
+			er.err = err
+		}
+	}
+
+	n := copy(p, er.out[er.off:])
+	er.off += n
+	return n, nil
+}
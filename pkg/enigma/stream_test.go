@@ -0,0 +1,196 @@
+package enigma
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncryptingWriter(t *testing.T) {
+	message := "the quick brown fox jumps over the lazy dog"
+
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := em.EncryptString(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// write the message in uneven chunks so groups span writes
+	for _, chunkSize := range []int{1, 3, 7, len(message)} {
+		em, err := setupEnigmaMachine()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		w := NewEncryptingWriter(&out, em)
+		for i := 0; i < len(message); i += chunkSize {
+			end := min(i+chunkSize, len(message))
+			n, err := w.Write([]byte(message[i:end]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != end-i {
+				t.Fatalf("expected %d bytes written, got %d", end-i, n)
+			}
+		}
+
+		if out.String() != expected {
+			t.Fatalf("chunk size %d: expected %s, got %s", chunkSize, expected, out.String())
+		}
+	}
+}
+
+func TestEncryptingWriter_Preserve(t *testing.T) {
+	// invalid UTF-8 is copied through by both paths
+	message := "Grüße aus Köln!\nBis bald.\xff\xfe"
+
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := em.EncryptFormat(message, FORMAT_PRESERVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(expected, "\xff\xfe") {
+		t.Fatalf("expected the invalid bytes to be kept, got %q", expected)
+	}
+
+	// chunks of one byte split the two byte characters
+	for _, chunkSize := range []int{1, 2, 5, len(message)} {
+		em, err := setupEnigmaMachine()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		w := NewEncryptingWriter(&out, em)
+		if err := w.SetFormat(FORMAT_PRESERVE); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(message); i += chunkSize {
+			if _, err := w.Write([]byte(message[i:min(i+chunkSize, len(message))])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if out.String() != expected {
+			t.Errorf("chunk size %d: expected %q, got %q", chunkSize, expected, out.String())
+		}
+	}
+
+	w := NewEncryptingWriter(io.Discard, em)
+	if err := w.SetFormat("fancy"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestEncryptingWriter_InvalidLetter(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	w := NewEncryptingWriter(&out, em)
+	n, err := w.Write([]byte("boot\ndev!"))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err.Error() != "invalid letter: !" {
+		t.Fatalf("expected invalid letter: !, got %s", err.Error())
+	}
+	if n != 8 {
+		t.Fatalf("expected 8 bytes written, got %d", n)
+	}
+	if out.String() != "WLQUC DI" {
+		t.Fatalf("expected WLQUC DI, got %s", out.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestEncryptingWriter_WriteError(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NewEncryptingWriter(failingWriter{}, em).Write([]byte("boot"))
+	if err != io.ErrClosedPipe {
+		t.Fatalf("expected %v, got %v", io.ErrClosedPipe, err)
+	}
+	if n != 4 {
+		t.Fatalf("expected the 4 letters encrypted to be counted, got %d", n)
+	}
+
+	// the machine has stepped past the letters that were lost
+	var out bytes.Buffer
+	if _, err := NewEncryptingWriter(&out, em).Write([]byte("dev")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "CDI" {
+		t.Fatalf("expected CDI, got %s", out.String())
+	}
+}
+
+func TestEncryptingReader(t *testing.T) {
+	message := strings.Repeat("bootdev rocks\n", 500)
+
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := em.EncryptString(strings.ReplaceAll(message, "\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"plain":    func(r io.Reader) io.Reader { return r },
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+	}
+
+	for name, wrap := range readers {
+		em, err := setupEnigmaMachine()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewEncryptingReader(wrap(strings.NewReader(message)), em)
+		out, err := io.ReadAll(iotest.OneByteReader(r))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(out) != expected {
+			t.Fatalf("%s: output does not match EncryptString", name)
+		}
+	}
+}
+
+func TestEncryptingReader_InvalidLetter(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewEncryptingReader(strings.NewReader("bootdev 2"), em)
+	out, err := io.ReadAll(r)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if string(out) != "WLQUC DI" {
+		t.Fatalf("expected WLQUC DI, got %s", string(out))
+	}
+}
@@ -0,0 +1,65 @@
+package enigma
+
+import "strings"
+
+// Hop is the signal passing through one component of the machine.
+type Hop struct {
+	Name string
+	In   rune
+	Out  rune
+}
+
+// RotorHop is the signal passing through a rotor, along with the rotor's
+// setting at the time. Slot counts the rotors from the left, starting at 0.
+type RotorHop struct {
+	Hop
+	Slot        int
+	Position    rune
+	RingSetting rune
+}
+
+// Trace is the full path of one letter through the machine, from the key
+// pressed to the lamp that lights up. Positions are the rotor positions after
+// the key press stepped the rotors, which is what the signal passes through.
+type Trace struct {
+	Key          rune
+	Positions    string
+	PlugboardIn  Hop
+	Forward      []RotorHop // right to left
+	Reflector    Hop
+	Backward     []RotorHop // left to right
+	PlugboardOut Hop
+	Lamp         rune
+}
+
+// EncryptTrace encrypts a single letter like a key press and returns the
+// path the signal took along with the lit lamp.
+func (e *EnigmaMachine) EncryptTrace(letter rune) (rune, Trace, error) {
+	if letter >= 'a' && letter <= 'z' {
+		letter -= 'a' - 'A'
+	}
+
+	var trace Trace
+	encrypted, err := e.encryptTraced(letter, &trace)
+	if err != nil {
+		return 0, Trace{}, err
+	}
+	return encrypted, trace, nil
+}
+
+func newRotorHop(slot int, r *Rotor, in, out rune) RotorHop {
+	return RotorHop{
+		Hop:         Hop{Name: r.name, In: in, Out: out},
+		Slot:        slot,
+		Position:    alphabetIndexToRune(r.position),
+		RingSetting: alphabetIndexToRune(r.ringSetting),
+	}
+}
+
+func (e *EnigmaMachine) positionLetters() string {
+	var positions strings.Builder
+	for _, rotor := range e.rotors {
+		positions.WriteRune(alphabetIndexToRune(rotor.position))
+	}
+	return positions.String()
+}
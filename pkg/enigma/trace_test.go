@@ -0,0 +1,102 @@
+package enigma
+
+import "testing"
+
+func TestEnigmaMachine_EncryptTrace(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	em.AddPlugboardConnection('B', 'X')
+
+	encrypted, trace, err := em.EncryptTrace('b')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if trace.Key != 'B' {
+		t.Errorf("expected key B, got %c", trace.Key)
+	}
+	if trace.Positions != "AAB" {
+		t.Errorf("expected positions AAB, got %s", trace.Positions)
+	}
+	if trace.PlugboardIn.In != 'B' || trace.PlugboardIn.Out != 'X' {
+		t.Errorf("expected plugboard B -> X, got %c -> %c", trace.PlugboardIn.In, trace.PlugboardIn.Out)
+	}
+	if trace.Lamp != encrypted {
+		t.Errorf("expected lamp %c, got %c", encrypted, trace.Lamp)
+	}
+
+	if len(trace.Forward) != 3 || len(trace.Backward) != 3 {
+		t.Fatalf("expected 3 rotor hops each way, got %d and %d", len(trace.Forward), len(trace.Backward))
+	}
+
+	// the forward path runs from the rightmost rotor to the leftmost one
+	if trace.Forward[0].Slot != 2 || trace.Forward[2].Slot != 0 {
+		t.Errorf("expected forward slots 2..0, got %d..%d", trace.Forward[0].Slot, trace.Forward[2].Slot)
+	}
+	if trace.Forward[0].Position != 'B' || trace.Forward[0].RingSetting != 'A' {
+		t.Errorf("expected rightmost rotor at B ring A, got %c ring %c", trace.Forward[0].Position, trace.Forward[0].RingSetting)
+	}
+
+	// every hop starts where the previous one ended
+	previous := trace.PlugboardIn.Out
+	hops := []Hop{}
+	for _, hop := range trace.Forward {
+		hops = append(hops, hop.Hop)
+	}
+	hops = append(hops, trace.Reflector)
+	for _, hop := range trace.Backward {
+		hops = append(hops, hop.Hop)
+	}
+	hops = append(hops, trace.PlugboardOut)
+	for _, hop := range hops {
+		if hop.In != previous {
+			t.Fatalf("hop %s: expected input %c, got %c", hop.Name, previous, hop.In)
+		}
+		previous = hop.Out
+	}
+	if previous != encrypted {
+		t.Fatalf("expected the path to end at %c, got %c", encrypted, previous)
+	}
+
+	// tracing encrypts exactly like a key press
+	em.Reset()
+	expected, err := em.encrypt('B')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected != encrypted {
+		t.Fatalf("expected %c, got %c", expected, encrypted)
+	}
+}
+
+func TestEnigmaMachine_EncryptTrace_Names(t *testing.T) {
+	em, err := DefaultConfig().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, trace, err := em.EncryptTrace('A')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if trace.Reflector.Name != "B" {
+		t.Errorf("expected reflector B, got %s", trace.Reflector.Name)
+	}
+	names := []string{trace.Forward[0].Name, trace.Forward[1].Name, trace.Forward[2].Name}
+	if names[0] != "I" || names[1] != "II" || names[2] != "III" {
+		t.Errorf("expected forward rotors [I II III], got %v", names)
+	}
+}
+
+func TestEnigmaMachine_EncryptTrace_Invalid(t *testing.T) {
+	em, err := setupEnigmaMachine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := em.EncryptTrace('1'); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
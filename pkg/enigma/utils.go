@@ -1,5 +1,41 @@
 package enigma
 
+import "fmt"
+
+// Normalize upper cases the text and drops the whitespace between groups and
+// lines, giving the letters as they are keyed. Anything else is an error. It
+// accepts exactly the messages the machine does.
+func Normalize(s string) (string, error) {
+	indices, err := normalizeIndices(s)
+	if err != nil {
+		return "", err
+	}
+	for i := range indices {
+		indices[i] += 'A'
+	}
+	return string(indices), nil
+}
+
+// normalizeIndices turns a message into letter indices, accepting lower case
+// letters and skipping whitespace. Only the ASCII letters are accepted, so
+// letters such as ı and ſ that upper case to I and S are errors.
+func normalizeIndices(message string) ([]byte, error) {
+	indices := make([]byte, 0, len(message))
+	for _, letter := range message {
+		if isSpace(letter) {
+			continue
+		}
+		if letter >= 'a' && letter <= 'z' {
+			letter -= 'a' - 'A'
+		}
+		if letter < 'A' || letter > 'Z' {
+			return nil, fmt.Errorf("invalid letter: %c", letter)
+		}
+		indices = append(indices, byte(runeToAlphabetIndex(letter)))
+	}
+	return indices, nil
+}
+
 func runeToAlphabetIndex(r rune) int {
 	return int(r - 'A')
 }
@@ -7,3 +43,13 @@ func runeToAlphabetIndex(r rune) int {
 func alphabetIndexToRune(i int) rune {
 	return rune(i + 'A')
 }
+
+// isSpace reports whether the character is whitespace skipped between the
+// letters of a message, such as the spaces between groups or line breaks.
+func isSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
@@ -71,3 +71,44 @@ func Test_alphabetIndexToRune(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "groups", text: "qwert zuiop", want: "QWERTZUIOP"},
+		{name: "lines", text: "QWERT\tZUIOP\r\nASDFG\n", want: "QWERTZUIOPASDFG"},
+		{name: "empty", text: "", want: ""},
+		{name: "digit", text: "ABC1", wantErr: true},
+		{name: "long s", text: "ſos", wantErr: true},
+		{name: "dotless i", text: "bıtte", wantErr: true},
+		{name: "umlaut", text: "über", wantErr: true},
+	}
+	em, err := Config{
+		Rotors:    []string{"III", "II", "I"},
+		Reflector: ReflectorConfig{Name: "B"},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+
+			// the machine accepts the same text
+			em.Reset()
+			if _, err := em.DecryptString(tt.text); (err != nil) != tt.wantErr {
+				t.Errorf("DecryptString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Package keysheet generates monthly key sheets, the Schlüsselblätter that
+// gave every operator of a key net the settings for each day.
+//
+// Sheets are drawn with crypto/rand and follow the rules the key makers
+// kept to: no rotor sits in the same slot two days in a row, and no
+// plugboard cable joins two letters next to each other in the alphabet.
+package keysheet
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// Options describes the key net the sheet is for.
+type Options struct {
+	// Name of the key net, printed on the sheet.
+	Name string
+	// Month printed on the sheet, such as 2026-10.
+	Month string
+	// Days in the month, defaults to 31.
+	Days int
+	// Model is M4 for a four rotor sheet, with a thin rotor on the left.
+	Model string
+	// Rotors to pick from, defaults to I to V, or I to VIII for the M4.
+	Rotors []string
+	// Reflector defaults to B, or B-thin for the M4.
+	Reflector string
+	// Plugs is the number of plugboard pairs, up to 10, defaults to 10.
+	Plugs int
+	// NoPlugs leaves the plugboard empty, for a sheet without cables.
+	NoPlugs bool
+	// Kenngruppen is the number of trigrams for each day, defaults to 4. No
+	// trigram is used twice in a month.
+	Kenngruppen int
+}
+
+// Day is the key for one day of the month.
+type Day struct {
+	Day         int           `json:"day"`
+	Config      enigma.Config `json:"config"`
+	Kenngruppen []string      `json:"kenngruppen"`
+}
+
+// Sheet is a month of daily keys.
+type Sheet struct {
+	Name  string `json:"name,omitempty"`
+	Month string `json:"month,omitempty"`
+	Days  []Day  `json:"days"`
+}
+
+// Generate draws a key sheet.
+func Generate(opts Options) (*Sheet, error) {
+	if opts.Days <= 0 {
+		opts.Days = 31
+	}
+	if opts.Plugs == 0 && !opts.NoPlugs {
+		opts.Plugs = 10
+	}
+	if opts.NoPlugs && opts.Plugs != 0 {
+		return nil, fmt.Errorf("no plugboard pairs asked for, but %d given", opts.Plugs)
+	}
+	if opts.Plugs < 0 || opts.Plugs > 10 {
+		return nil, fmt.Errorf("plugboard pairs must be between 0 and 10, got %d", opts.Plugs)
+	}
+	if opts.Kenngruppen <= 0 {
+		opts.Kenngruppen = 4
+	}
+	if opts.Days*opts.Kenngruppen > 26*26*26 {
+		return nil, fmt.Errorf("%d days of %d Kenngruppen need more than the %d trigrams", opts.Days, opts.Kenngruppen, 26*26*26)
+	}
+
+	orders, err := rotorOrders(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &Sheet{Name: opts.Name, Month: opts.Month}
+	used := map[string]bool{}
+	var previous []string
+	for day := 1; day <= opts.Days; day++ {
+		order, err := pickOrder(orders, previous)
+		if err != nil {
+			return nil, err
+		}
+		previous = order
+
+		rings, err := randomLetters(len(order))
+		if err != nil {
+			return nil, err
+		}
+		pairs, err := plugboardPairs(opts.Plugs)
+		if err != nil {
+			return nil, err
+		}
+
+		kenngruppen := []string{}
+		for len(kenngruppen) < opts.Kenngruppen {
+			group, err := randomLetters(3)
+			if err != nil {
+				return nil, err
+			}
+			if used[group] {
+				continue
+			}
+			used[group] = true
+			kenngruppen = append(kenngruppen, group)
+		}
+
+		config := enigma.Config{
+			Model:             opts.Model,
+			Rotors:            order,
+			RotorRingSettings: rings,
+			Reflector:         enigma.ReflectorConfig{Name: opts.Reflector},
+			Plugboard:         enigma.PlugboardConfig{Pairs: pairs},
+		}
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		sheet.Days = append(sheet.Days, Day{Day: day, Config: config, Kenngruppen: kenngruppen})
+	}
+	return sheet, nil
+}
+
+// rotorOrders lists the rotor orders the sheet can use, filling in the
+// defaults of the model.
+func rotorOrders(opts *Options) ([][]string, error) {
+	m4 := opts.Model == enigma.MODEL_M4
+	if len(opts.Rotors) == 0 {
+		opts.Rotors = []string{"I", "II", "III", "IV", "V"}
+		if m4 {
+			opts.Rotors = append(opts.Rotors, "VI", "VII", "VIII")
+		}
+	}
+	if opts.Reflector == "" {
+		opts.Reflector = "B"
+		if m4 {
+			opts.Reflector = "B-thin"
+		}
+	}
+
+	orders := enigma.RotorOrders(opts.Rotors, 3)
+	if m4 {
+		thin := [][]string{}
+		for _, rotor := range []string{"Beta", "Gamma"} {
+			for _, order := range orders {
+				thin = append(thin, append([]string{rotor}, order...))
+			}
+		}
+		orders = thin
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("at least 3 rotors are needed, got %d", len(opts.Rotors))
+	}
+	return orders, nil
+}
+
+// pickOrder draws a rotor order that puts no rotor in the slot it had the
+// day before.
+func pickOrder(orders [][]string, previous []string) ([]string, error) {
+	allowed := slices.DeleteFunc(slices.Clone(orders), func(order []string) bool {
+		for i := range previous {
+			if order[i] == previous[i] {
+				return true
+			}
+		}
+		return false
+	})
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("no rotor order moves every rotor from %v, more rotors are needed", previous)
+	}
+	i, err := randomInt(len(allowed))
+	if err != nil {
+		return nil, err
+	}
+	return allowed[i], nil
+}
+
+// plugboardPairs draws pairs of letters that are not next to each other in
+// the alphabet.
+func plugboardPairs(n int) ([]string, error) {
+	for {
+		letters := []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		for i := len(letters) - 1; i > 0; i-- {
+			j, err := randomInt(i + 1)
+			if err != nil {
+				return nil, err
+			}
+			letters[i], letters[j] = letters[j], letters[i]
+		}
+
+		pairs := []string{}
+		for i := 0; i < 2*n; i += 2 {
+			a, b := min(letters[i], letters[i+1]), max(letters[i], letters[i+1])
+			if b-a == 1 {
+				break
+			}
+			pairs = append(pairs, string([]byte{a, b}))
+		}
+		if len(pairs) == n {
+			slices.Sort(pairs)
+			return pairs, nil
+		}
+	}
+}
+
+func randomLetters(n int) (string, error) {
+	l := make([]byte, n)
+	for i := range l {
+		r, err := randomInt(26)
+		if err != nil {
+			return "", err
+		}
+		l[i] = byte('A' + r)
+	}
+	return string(l), nil
+}
+
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// Day returns the key for a day of the month.
+func (s *Sheet) Day(day int) (Day, error) {
+	for _, d := range s.Days {
+		if d.Day == day {
+			return d, nil
+		}
+	}
+	return Day{}, fmt.Errorf("the sheet has no day %d", day)
+}
+
+// WriteText prints the sheet as a table to be handed out. Like the paper
+// sheets, the last day of the month comes first, so the days used up can be
+// cut off and destroyed.
+func (s *Sheet) WriteText(w io.Writer) error {
+	title := "Schlüsselblatt"
+	for _, part := range []string{s.Name, s.Month} {
+		if part != "" {
+			title += " " + part
+		}
+	}
+	if _, err := fmt.Fprintf(w, "GEHEIM! %s\n\n", title); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DAY\tROTORS\tRINGS\tREFLECTOR\tPLUGBOARD\tKENNGRUPPEN")
+	for i := len(s.Days) - 1; i >= 0; i-- {
+		d := s.Days[i]
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			d.Day,
+			strings.Join(d.Config.Rotors, " "),
+			strings.Join(strings.Split(d.Config.RotorRingSettings, ""), " "),
+			d.Config.Reflector,
+			strings.Join(d.Config.Plugboard.Pairs, " "),
+			strings.Join(d.Kenngruppen, " "),
+		)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the sheet as JSON. The config of each day uses the schema
+// of the config file.
+func (s *Sheet) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(s)
+}
+
+// LoadSheet reads a sheet written by WriteJSON, checking the settings of every
+// day.
+func LoadSheet(r io.Reader) (*Sheet, error) {
+	s := &Sheet{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	for _, d := range s.Days {
+		if err := d.Config.Validate(); err != nil {
+			return nil, fmt.Errorf("day %d: %w", d.Day, err)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,176 @@
+package keysheet
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		slots int
+	}{
+		{"default", Options{}, 3},
+		{"three rotors", Options{Days: 30, Rotors: []string{"I", "II", "III"}, Plugs: 6}, 3},
+		{"no plugs", Options{Days: 5, NoPlugs: true}, 3},
+		{"M4", Options{Model: enigma.MODEL_M4}, 4},
+	}
+
+	for _, test := range tests {
+		sheet, err := Generate(test.opts)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		days := test.opts.Days
+		if days == 0 {
+			days = 31
+		}
+		if len(sheet.Days) != days {
+			t.Errorf("%s: expected %d days, got %d", test.name, days, len(sheet.Days))
+			continue
+		}
+
+		kenngruppen := map[string]bool{}
+		for i, d := range sheet.Days {
+			if d.Day != i+1 {
+				t.Errorf("%s: expected day %d, got %d", test.name, i+1, d.Day)
+			}
+			if err := d.Config.Validate(); err != nil {
+				t.Errorf("%s: day %d: %v", test.name, d.Day, err)
+			}
+			if len(d.Config.Rotors) != test.slots || len(d.Config.RotorRingSettings) != test.slots {
+				t.Errorf("%s: day %d: expected %d rotors and rings, got %v %s", test.name, d.Day, test.slots, d.Config.Rotors, d.Config.RotorRingSettings)
+			}
+
+			if i > 0 {
+				for slot, rotor := range d.Config.Rotors {
+					if sheet.Days[i-1].Config.Rotors[slot] == rotor {
+						t.Errorf("%s: day %d: rotor %s in the same slot as the day before", test.name, d.Day, rotor)
+					}
+				}
+			}
+
+			plugs := test.opts.Plugs
+			if plugs == 0 && !test.opts.NoPlugs {
+				plugs = 10
+			}
+			if len(d.Config.Plugboard.Pairs) != plugs {
+				t.Errorf("%s: day %d: expected %d plugboard pairs, got %v", test.name, d.Day, plugs, d.Config.Plugboard.Pairs)
+			}
+			for _, pair := range d.Config.Plugboard.Pairs {
+				if pair[1]-pair[0] == 1 || pair[0]-pair[1] == 1 {
+					t.Errorf("%s: day %d: pair %s joins neighbouring letters", test.name, d.Day, pair)
+				}
+			}
+
+			if len(d.Kenngruppen) != 4 {
+				t.Errorf("%s: day %d: expected 4 Kenngruppen, got %v", test.name, d.Day, d.Kenngruppen)
+			}
+			for _, group := range d.Kenngruppen {
+				if kenngruppen[group] {
+					t.Errorf("%s: Kenngruppe %s used twice", test.name, group)
+				}
+				kenngruppen[group] = true
+			}
+		}
+	}
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	tests := []Options{
+		{Rotors: []string{"I", "II"}},
+		{Plugs: 11},
+		{Plugs: -1},
+		{Plugs: 4, NoPlugs: true},
+		{Kenngruppen: 600},
+		{Days: 1, Kenngruppen: 26*26*26 + 1},
+		{Rotors: []string{"I", "II", "IX"}},
+		{Reflector: "E"},
+	}
+	for _, test := range tests {
+		if _, err := Generate(test); err == nil {
+			t.Errorf("%+v: expected error, got nil", test)
+		}
+	}
+}
+
+func TestSheet_JSON(t *testing.T) {
+	sheet, err := Generate(Options{Name: "Heer", Month: "2026-10", Days: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := sheet.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSheet(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "Heer" || loaded.Month != "2026-10" || len(loaded.Days) != 3 {
+		t.Fatalf("expected the sheet back, got %+v", loaded)
+	}
+
+	day, err := loaded.Day(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := sheet.Days[1]
+	if !slices.Equal(day.Config.Rotors, expected.Config.Rotors) ||
+		day.Config.RotorRingSettings != expected.Config.RotorRingSettings ||
+		!slices.Equal(day.Config.Plugboard.Pairs, expected.Config.Plugboard.Pairs) ||
+		!slices.Equal(day.Kenngruppen, expected.Kenngruppen) {
+		t.Errorf("expected %+v, got %+v", expected, day)
+	}
+	if _, err := loaded.Day(4); err == nil {
+		t.Error("expected error for a missing day, got nil")
+	}
+
+	// a day's config can be used as a config file
+	data, err := enigma.MarshalConfig(day.Config, enigma.CONFIG_FORMAT_JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := enigma.UnmarshalConfig(data, enigma.CONFIG_FORMAT_JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSheet_WriteText(t *testing.T) {
+	sheet, err := Generate(Options{Name: "Heer", Month: "2026-10", Days: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := sheet.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "GEHEIM! Schlüsselblatt Heer 2026-10" {
+		t.Errorf("unexpected title %q", lines[0])
+	}
+	if len(lines) != 6 {
+		t.Fatalf("expected a title, a header and 3 days, got %q", buf.String())
+	}
+	// the last day comes first
+	for i, day := range []string{"3", "2", "1"} {
+		if fields := strings.Fields(lines[3+i]); fields[0] != day {
+			t.Errorf("expected day %s on line %d, got %q", day, 3+i, lines[3+i])
+		}
+	}
+	if !strings.Contains(lines[3], strings.Join(sheet.Days[2].Kenngruppen, " ")) {
+		t.Errorf("expected the Kenngruppen of day 3 on %q", lines[3])
+	}
+}
@@ -0,0 +1,70 @@
+package naval
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Indicator holds the two trigrams an operator picked from the
+// Kenngruppenbuch for a message.
+type Indicator struct {
+	// Kenngruppe is the Schlüsselkenngruppe, naming the key net.
+	Kenngruppe string
+	// Verfahrenkenngruppe is encrypted at the Grundstellung to give the
+	// message key.
+	Verfahrenkenngruppe string
+}
+
+// Encode writes the indicator as its two four letter groups, with random
+// filler letters.
+func (t *BigramTable) Encode(i Indicator) ([2]string, error) {
+	fill := make([]byte, 2)
+	for j := range fill {
+		l, err := rand.Int(rand.Reader, big.NewInt(26))
+		if err != nil {
+			return [2]string{}, err
+		}
+		fill[j] = byte('A' + l.Int64())
+	}
+	return t.encode(i, string(fill))
+}
+
+// encode writes the indicator with the filler letters given, the first
+// ahead of the Kenngruppe and the second after the Verfahrenkenngruppe.
+func (t *BigramTable) encode(i Indicator, fill string) ([2]string, error) {
+	top := strings.ToUpper(fill[:1] + i.Kenngruppe)
+	bottom := strings.ToUpper(i.Verfahrenkenngruppe + fill[1:])
+	if len(top) != 4 || len(bottom) != 4 {
+		return [2]string{}, fmt.Errorf("the Kenngruppe and Verfahrenkenngruppe must be 3 letters long")
+	}
+
+	groups := [2][]byte{make([]byte, 4), make([]byte, 4)}
+	for j := 0; j < 4; j++ {
+		swapped, err := t.Swap(string([]byte{top[j], bottom[j]}))
+		if err != nil {
+			return [2]string{}, err
+		}
+		groups[0][j], groups[1][j] = swapped[0], swapped[1]
+	}
+	return [2]string{string(groups[0]), string(groups[1])}, nil
+}
+
+// Decode reads an indicator from its two four letter groups.
+func (t *BigramTable) Decode(groups [2]string) (Indicator, error) {
+	top, bottom := strings.ToUpper(groups[0]), strings.ToUpper(groups[1])
+	if len(top) != 4 || len(bottom) != 4 {
+		return Indicator{}, fmt.Errorf("indicator groups %s %s must be 4 letters long", groups[0], groups[1])
+	}
+
+	rows := [2][]byte{make([]byte, 4), make([]byte, 4)}
+	for j := 0; j < 4; j++ {
+		swapped, err := t.Swap(string([]byte{top[j], bottom[j]}))
+		if err != nil {
+			return Indicator{}, err
+		}
+		rows[0][j], rows[1][j] = swapped[0], swapped[1]
+	}
+	return Indicator{Kenngruppe: string(rows[0][1:]), Verfahrenkenngruppe: string(rows[1][:3])}, nil
+}
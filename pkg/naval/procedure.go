@@ -0,0 +1,166 @@
+package naval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// PROCEDURE_NAVAL names the naval indicator procedure, alongside the message
+// key procedures of the enigma package.
+const PROCEDURE_NAVAL = "naval"
+
+// Procedure sends messages on a machine set to the day's key, usually the
+// four rotor M4, with its rotor positions at the Grundstellung.
+type Procedure struct {
+	Machine *enigma.EnigmaMachine
+	Bigrams *BigramTable
+	// Kenngruppenbuch is where trigrams are picked from when encrypting and
+	// checked against when decrypting. It can be left out when the indicator
+	// is given.
+	Kenngruppenbuch *Kenngruppenbuch
+}
+
+// MessageKey encrypts the Verfahrenkenngruppe at the Grundstellung to give
+// the positions the rotors are turned to for the message. On the M4 the
+// thin rotor stays at its Grundstellung, so only the three rotors to its
+// right are set from the trigram.
+func (p *Procedure) MessageKey(verfahrenkenngruppe string) (string, error) {
+	p.Machine.Reset()
+	ground := p.Machine.GetRotorPositions()
+	if len(ground) < 3 {
+		return "", fmt.Errorf("the naval procedure needs at least 3 rotors, got %d", len(ground))
+	}
+
+	key, err := p.Machine.DecryptString(verfahrenkenngruppe)
+	if err != nil {
+		return "", err
+	}
+	if len(key) != 3 {
+		return "", fmt.Errorf("the Verfahrenkenngruppe %s must be 3 letters long", verfahrenkenngruppe)
+	}
+	p.Machine.Reset()
+
+	var prefix strings.Builder
+	for _, position := range ground[:len(ground)-3] {
+		prefix.WriteRune(rune('A' + position))
+	}
+	return prefix.String() + key, nil
+}
+
+// Encrypt sends a message with trigrams picked from the Kenngruppenbuch,
+// keeping the Kenngruppe of the key net when one is given.
+func (p *Procedure) Encrypt(kenngruppe, message string) (string, error) {
+	if p.Kenngruppenbuch == nil {
+		return "", fmt.Errorf("a Kenngruppenbuch is needed to pick the trigrams")
+	}
+
+	i := Indicator{Kenngruppe: kenngruppe}
+	var err error
+	if i.Kenngruppe == "" {
+		if i.Kenngruppe, err = p.Kenngruppenbuch.Random(); err != nil {
+			return "", err
+		}
+	}
+	if i.Verfahrenkenngruppe, err = p.Kenngruppenbuch.Random(); err != nil {
+		return "", err
+	}
+	groups, err := p.Bigrams.Encode(i)
+	if err != nil {
+		return "", err
+	}
+	return p.encrypt(i, groups, message)
+}
+
+// EncryptWith sends a message with the indicator given, written with the two
+// filler letters given.
+func (p *Procedure) EncryptWith(i Indicator, fill, message string) (string, error) {
+	if len(fill) != 2 {
+		return "", fmt.Errorf("fill %s must be 2 letters long", fill)
+	}
+	groups, err := p.Bigrams.encode(i, fill)
+	if err != nil {
+		return "", err
+	}
+	return p.encrypt(i, groups, message)
+}
+
+// encrypt writes the indicator groups, the message in four letter groups,
+// and the indicator groups again, as the signal was sent.
+func (p *Procedure) encrypt(i Indicator, groups [2]string, message string) (string, error) {
+	key, err := p.MessageKey(i.Verfahrenkenngruppe)
+	if err != nil {
+		return "", err
+	}
+
+	ground := p.Machine.GetRotorPositions()
+	defer p.Machine.SetRotorPositions(util.Letters(ground))
+
+	if err := p.Machine.SetRotorPositions(strings.Split(key, "")); err != nil {
+		return "", err
+	}
+	body, err := p.Machine.DecryptString(message)
+	if err != nil {
+		return "", err
+	}
+
+	text := []string{groups[0], groups[1]}
+	text = append(text, fourLetterGroups(body)...)
+	text = append(text, groups[0], groups[1])
+	return strings.Join(text, " "), nil
+}
+
+// Decrypt reads the indicator groups at the start of a signal, recovers the
+// message key and decrypts the rest. The repeated groups at the end are
+// dropped when present.
+func (p *Procedure) Decrypt(signal string) (Indicator, string, string, error) {
+	text := strings.ToUpper(strings.Join(strings.Fields(signal), ""))
+	if len(text) < 8 {
+		return Indicator{}, "", "", fmt.Errorf("the signal must start with two 4 letter indicator groups")
+	}
+	groups := [2]string{text[:4], text[4:8]}
+	body := text[8:]
+	if strings.HasSuffix(body, text[:8]) {
+		body = body[:len(body)-8]
+	}
+
+	i, err := p.Bigrams.Decode(groups)
+	if err != nil {
+		return i, "", "", err
+	}
+	if p.Kenngruppenbuch != nil {
+		for _, group := range []string{i.Kenngruppe, i.Verfahrenkenngruppe} {
+			if !p.Kenngruppenbuch.Contains(group) {
+				return i, "", "", fmt.Errorf("%s is not in the Kenngruppenbuch, the bigram tables may be wrong", group)
+			}
+		}
+	}
+
+	key, err := p.MessageKey(i.Verfahrenkenngruppe)
+	if err != nil {
+		return i, "", "", err
+	}
+
+	ground := p.Machine.GetRotorPositions()
+	defer p.Machine.SetRotorPositions(util.Letters(ground))
+
+	if err := p.Machine.SetRotorPositions(strings.Split(key, "")); err != nil {
+		return i, "", "", err
+	}
+	plaintext, err := p.Machine.DecryptString(body)
+	if err != nil {
+		return i, "", "", err
+	}
+	return i, key, plaintext, nil
+}
+
+// fourLetterGroups splits text into the four letter groups of naval traffic.
+func fourLetterGroups(text string) []string {
+	groups := []string{}
+	for i := 0; i < len(text); i += 4 {
+		groups = append(groups, text[i:min(i+4, len(text))])
+	}
+	return groups
+}
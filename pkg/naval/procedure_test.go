@@ -0,0 +1,189 @@
+package naval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+func setupProcedure(t *testing.T) *Procedure {
+	em, err := enigma.Config{
+		Model:             "M4",
+		Rotors:            []string{"Beta", "II", "IV", "I"},
+		RotorPositions:    "CJRT",
+		RotorRingSettings: "AAAV",
+		Reflector:         enigma.ReflectorConfig{Name: "B-thin"},
+		Plugboard:         enigma.PlugboardConfig{Pairs: []string{"AT", "BL", "DF", "GJ", "HM", "NW", "OP", "QY", "RZ", "VX"}},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := LoadKenngruppenbuch(strings.NewReader("SWQ RAF KLM TUV"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Procedure{Machine: em, Bigrams: randomBigramTable(t, 534), Kenngruppenbuch: book}
+}
+
+func TestBigramTable_EncodeDecode(t *testing.T) {
+	table := randomBigramTable(t, 534)
+	i := Indicator{Kenngruppe: "SWQ", Verfahrenkenngruppe: "RAF"}
+
+	groups, err := table.encode(i, "XY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pairs XR, SA, WF and QY are swapped
+	for j, pair := range []string{"XR", "SA", "WF", "QY"} {
+		swapped, _ := table.Swap(pair)
+		if groups[0][j] != swapped[0] || groups[1][j] != swapped[1] {
+			t.Fatalf("expected %s in column %d, got %c%c", swapped, j, groups[0][j], groups[1][j])
+		}
+	}
+
+	decoded, err := table.Decode(groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != i {
+		t.Errorf("expected %v, got %v", i, decoded)
+	}
+
+	if _, err := table.encode(Indicator{Kenngruppe: "SW", Verfahrenkenngruppe: "RAF"}, "XY"); err == nil {
+		t.Error("expected error for a short Kenngruppe, got nil")
+	}
+	if _, err := table.Decode([2]string{"ABC", "DEFG"}); err == nil {
+		t.Error("expected error for a short group, got nil")
+	}
+}
+
+func TestProcedure(t *testing.T) {
+	p := setupProcedure(t)
+	i := Indicator{Kenngruppe: "SWQ", Verfahrenkenngruppe: "RAF"}
+
+	key, err := p.MessageKey("RAF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the thin rotor keeps its Grundstellung
+	if len(key) != 4 || key[0] != 'C' {
+		t.Fatalf("expected a message key starting with C, got %s", key)
+	}
+
+	signal, err := p.EncryptWith(i, "XY", "von von u boot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := strings.Fields(signal)
+	if groups[0] != groups[len(groups)-2] || groups[1] != groups[len(groups)-1] {
+		t.Errorf("expected the indicator groups to be repeated at the end, got %s", signal)
+	}
+	for _, group := range groups[:len(groups)-3] {
+		if len(group) != 4 {
+			t.Errorf("expected four letter groups, got %s", signal)
+			break
+		}
+	}
+
+	decoded, decodedKey, plaintext, err := p.Decrypt(signal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != i || decodedKey != key || plaintext != "VONVONUBOOT" {
+		t.Errorf("expected %v %s VONVONUBOOT, got %v %s %s", i, key, decoded, decodedKey, plaintext)
+	}
+
+	// without the repeated groups
+	short := strings.Join(groups[:len(groups)-2], " ")
+	if _, _, plaintext, err := p.Decrypt(short); err != nil || plaintext != "VONVONUBOOT" {
+		t.Errorf("expected VONVONUBOOT, got %s %v", plaintext, err)
+	}
+
+	if positions := p.Machine.GetRotorPositions(); positions[1] != 'J'-'A' || positions[2] != 'R'-'A' {
+		t.Errorf("expected the machine back at the Grundstellung, got %v", positions)
+	}
+
+	random, err := p.Encrypt("SWQ", "von von u boot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, plaintext, err = p.Decrypt(random)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Kenngruppe != "SWQ" || plaintext != "VONVONUBOOT" {
+		t.Errorf("expected SWQ VONVONUBOOT, got %v %s", decoded, plaintext)
+	}
+}
+
+// The signal from Kptlt. Looks on U-264, broken by the M4 Message Breaking
+// Project in 2006 with the key Beta II IV I, UKW B-thin, rings AAAV, plugs AT
+// BL DF GJ HM NW OP QY RZ VX and the message key VJNA.
+const (
+	looksCiphertext = "NCZW VUSX PNYM INHZ XMQX SFWX WLKJ AHSH NMCO CCAK UQPM KCSM HKSE INJU SBLK IOSX CKUB HMLL XCSJ USRR DVKO HULX WCCB GVLI YXEO AHXR HKKF VDRE WEZL XOBA FGYU JQUK GRTV UKAM EURB VEKS UHHV OYHA BCJW MAKL FKLM YFVN RIZR VVRT KOFD ANJM OLBG FFLE OPRG TFLV RHOW OPBE KVWM UQFM PWPA RMFH AGKX IIBG"
+	looksPlaintext  = "VONVONJLOOKSJHFFTTTEINSEINSDREIZWOYYQNNSNEUNINHALTXXBEIANGRIFFUNTERWASSERGEDRUECKTYWABOSXLETZTERGEGNERSTANDNULACHTDREINULUHRMARQUANTONJOTANEUNACHTSEYHSDREIYZWOZWONULGRADYACHTSMYSTOSSENACHXEKNSVIERMBFAELLTYNNNNNNOOOVIERYSICHTEINSNULL"
+)
+
+func TestProcedure_Looks(t *testing.T) {
+	em, err := enigma.Config{
+		Model:             "M4",
+		Rotors:            []string{"Beta", "II", "IV", "I"},
+		RotorPositions:    "VJNA",
+		RotorRingSettings: "AAAV",
+		Reflector:         enigma.ReflectorConfig{Name: "B-thin"},
+		Plugboard:         enigma.PlugboardConfig{Pairs: []string{"AT", "BL", "DF", "GJ", "HM", "NW", "OP", "QY", "RZ", "VX"}},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := em.DecryptString(looksCiphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != looksPlaintext {
+		t.Fatalf("expected %s, got %s", looksPlaintext, plaintext)
+	}
+
+	// The indicator of the signal and the day's Grundstellung and bigram
+	// table were not recovered, so the signal is read here with the
+	// Grundstellung VKQE, where the Verfahrenkenngruppe MOG gives the
+	// message key VJNA, and with the Kenngruppe DKW and filler letters X and
+	// P swapped through this sample table.
+	if err := em.SetRotorPositions([]string{"V", "K", "Q", "E"}); err != nil {
+		t.Fatal(err)
+	}
+	table, err := LoadBigramTable(strings.NewReader("XM QA\nDO LT\nKG EZ\nWP NC\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Procedure{Machine: em, Bigrams: table}
+
+	signal := "QLEN ATZC " + looksCiphertext + " QLEN ATZC"
+	i, key, plaintext, err := p.Decrypt(signal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Kenngruppe != "DKW" || i.Verfahrenkenngruppe != "MOG" || key != "VJNA" {
+		t.Errorf("expected Kenngruppe DKW, Verfahrenkenngruppe MOG and key VJNA, got %v %s", i, key)
+	}
+	if plaintext != looksPlaintext {
+		t.Errorf("expected %s, got %s", looksPlaintext, plaintext)
+	}
+}
+
+func TestProcedure_WrongTable(t *testing.T) {
+	p := setupProcedure(t)
+	signal, err := p.EncryptWith(Indicator{Kenngruppe: "SWQ", Verfahrenkenngruppe: "RAF"}, "XY", "von von u boot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Bigrams = randomBigramTable(t, 1)
+	if _, _, _, err := p.Decrypt(signal); err == nil {
+		t.Error("expected error with the wrong bigram table, got nil")
+	}
+	if _, _, _, err := p.Decrypt("ABCD"); err == nil {
+		t.Error("expected error for a short signal, got nil")
+	}
+}
@@ -0,0 +1,196 @@
+// Package naval implements the indicator procedure of the Kriegsmarine, which
+// hid the message key of naval traffic far better than the army and air
+// force procedures did.
+//
+// The operator took two trigrams from the Kenngruppenbuch. The first, the
+// Schlüsselkenngruppe, named the key net. The second, the
+// Verfahrenkenngruppe, was encrypted at the daily Grundstellung to give the
+// message key. Both were written one above the other, shifted by a random
+// filler letter:
+//
+//	a S W Q
+//	R A F b
+//
+// and the vertical pairs aR, SA, WF and Qb replaced using the day's bigram
+// table. The top and bottom letters of the replaced pairs make the two four
+// letter indicator groups, sent at the start of the message and repeated at
+// its end.
+package naval
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// BigramTable is a Doppelbuchstabentauschtafel: it swaps pairs of letters
+// with other pairs, in both directions.
+type BigramTable struct {
+	swaps map[string]string
+}
+
+// NewBigramTable builds a table from the swaps, each pair listed once or in
+// both directions.
+func NewBigramTable(swaps map[string]string) (*BigramTable, error) {
+	t := &BigramTable{swaps: map[string]string{}}
+	for from, to := range swaps {
+		if err := t.add(from, to); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *BigramTable) add(from, to string) error {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	for _, bigram := range []string{from, to} {
+		if len(bigram) != 2 || !isLetters(bigram) {
+			return fmt.Errorf("invalid bigram: %s", bigram)
+		}
+	}
+	for a, b := range map[string]string{from: to, to: from} {
+		if existing, ok := t.swaps[a]; ok && existing != b {
+			return fmt.Errorf("bigram %s is swapped with both %s and %s", a, existing, b)
+		}
+		t.swaps[a] = b
+	}
+	return nil
+}
+
+// LoadBigramTable reads a table with a swap on each line, written as
+// "AB CD" or "AB=CD". Blank lines and lines starting with # are skipped.
+func LoadBigramTable(r io.Reader) (*BigramTable, error) {
+	t := &BigramTable{swaps: map[string]string{}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.ReplaceAll(text, "=", " "))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected two bigrams, got %q", line, text)
+		}
+		if err := t.add(fields[0], fields[1]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadBigramTableFile reads a table from a file written for LoadBigramTable.
+func LoadBigramTableFile(name string) (*BigramTable, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := LoadBigramTable(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// Swap returns the bigram the table swaps with the given one.
+func (t *BigramTable) Swap(bigram string) (string, error) {
+	swapped, ok := t.swaps[strings.ToUpper(bigram)]
+	if !ok {
+		return "", fmt.Errorf("bigram %s is not in the table", bigram)
+	}
+	return swapped, nil
+}
+
+// Complete reports whether the table swaps all 676 bigrams.
+func (t *BigramTable) Complete() bool {
+	return len(t.swaps) == 26*26
+}
+
+// Kenngruppenbuch is the list of trigrams the operators picked their
+// Schlüsselkenngruppe and Verfahrenkenngruppe from.
+type Kenngruppenbuch struct {
+	groups []string
+	index  map[string]bool
+}
+
+// LoadKenngruppenbuch reads trigrams separated by spaces or new lines. Lines
+// starting with # are skipped.
+func LoadKenngruppenbuch(r io.Reader) (*Kenngruppenbuch, error) {
+	k := &Kenngruppenbuch{index: map[string]bool{}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		for _, group := range strings.Fields(strings.ToUpper(text)) {
+			if len(group) != 3 || !isLetters(group) {
+				return nil, fmt.Errorf("line %d: invalid trigram %s", line, group)
+			}
+			if k.index[group] {
+				continue
+			}
+			k.index[group] = true
+			k.groups = append(k.groups, group)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(k.groups) == 0 {
+		return nil, fmt.Errorf("the Kenngruppenbuch has no trigrams")
+	}
+	return k, nil
+}
+
+// LoadKenngruppenbuchFile reads trigrams from a file written for
+// LoadKenngruppenbuch.
+func LoadKenngruppenbuchFile(name string) (*Kenngruppenbuch, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	k, err := LoadKenngruppenbuch(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return k, nil
+}
+
+// Contains reports whether the trigram is in the book.
+func (k *Kenngruppenbuch) Contains(group string) bool {
+	return k.index[strings.ToUpper(group)]
+}
+
+// Len returns the number of trigrams in the book.
+func (k *Kenngruppenbuch) Len() int {
+	return len(k.groups)
+}
+
+// Random picks a trigram from the book.
+func (k *Kenngruppenbuch) Random() (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(k.groups))))
+	if err != nil {
+		return "", err
+	}
+	return k.groups[i.Int64()], nil
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package naval
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomBigramTable pairs up all 676 bigrams at random.
+func randomBigramTable(t *testing.T, seed int64) *BigramTable {
+	perm := rand.New(rand.NewSource(seed)).Perm(26 * 26)
+	swaps := map[string]string{}
+	bigram := func(i int) string { return string([]byte{byte('A' + i/26), byte('A' + i%26)}) }
+	for i := 0; i < len(perm); i += 2 {
+		swaps[bigram(perm[i])] = bigram(perm[i+1])
+	}
+	table, err := NewBigramTable(swaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestLoadBigramTable(t *testing.T) {
+	table, err := LoadBigramTable(strings.NewReader("# day 1\nAA QX\n\nbc=DE\nQX AA\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{"AA": "QX", "QX": "AA", "BC": "DE", "de": "BC"}
+	for bigram, expected := range tests {
+		swapped, err := table.Swap(bigram)
+		if err != nil {
+			t.Errorf("%s: %v", bigram, err)
+			continue
+		}
+		if swapped != expected {
+			t.Errorf("%s: expected %s, got %s", bigram, expected, swapped)
+		}
+	}
+	if _, err := table.Swap("ZZ"); err == nil {
+		t.Error("expected error for a missing bigram, got nil")
+	}
+	if table.Complete() {
+		t.Error("expected the table not to be complete")
+	}
+	if !randomBigramTable(t, 1).Complete() {
+		t.Error("expected the random table to be complete")
+	}
+}
+
+func TestLoadBigramTable_Invalid(t *testing.T) {
+	tests := []string{
+		"AA QX\nAA QY",
+		"AA QX\nQX BB",
+		"AAA QX",
+		"A1 QX",
+		"AA QX RS",
+	}
+	for _, test := range tests {
+		if _, err := LoadBigramTable(strings.NewReader(test)); err == nil {
+			t.Errorf("%q: expected error, got nil", test)
+		}
+	}
+}
+
+func TestLoadKenngruppenbuch(t *testing.T) {
+	book, err := LoadKenngruppenbuch(strings.NewReader("# column 1\nswq raf\nKLM SWQ\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Len() != 3 {
+		t.Errorf("expected 3 trigrams, got %d", book.Len())
+	}
+	if !book.Contains("RAF") || book.Contains("ABC") {
+		t.Error("expected RAF but not ABC in the book")
+	}
+	group, err := book.Random()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !book.Contains(group) {
+		t.Errorf("expected a trigram from the book, got %s", group)
+	}
+
+	for _, test := range []string{"", "# nothing", "SW", "SWQ R4F"} {
+		if _, err := LoadKenngruppenbuch(strings.NewReader(test)); err == nil {
+			t.Errorf("%q: expected error, got nil", test)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package rejewski
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// Options chooses the rotor orders the catalogue covers.
+type Options struct {
+	// RotorOrders are the rotor orders to cover, left to right. When empty,
+	// every order of three of Rotors is covered.
+	RotorOrders [][]string
+	// Rotors to build the orders from, defaults to I, II and III, the rotors
+	// of the time.
+	Rotors []string
+	// Reflector defaults to B.
+	Reflector string
+	// Workers is the number of rotor orders worked on at once, defaults to
+	// the number of CPUs.
+	Workers int
+}
+
+// Entry is a daily key setting the catalogue lists for a characteristic.
+type Entry struct {
+	Rotors   []string `json:"rotors"`
+	Position string   `json:"position"`
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %s", strings.Join(e.Rotors, " "), e.Position)
+}
+
+// Catalogue lists the rotor orders and start positions that give each
+// characteristic. Like Rejewski's card catalogue, it is built with the rings
+// at A, and the rotors step as in the machine over the six indicator letters,
+// turnovers included. The ring settings are found afterwards, so a position
+// from the catalogue is the daily start position shifted by the rings. The
+// day's characteristic only differs from the entry when the rings move a
+// turnover into or out of the indicator.
+type Catalogue struct {
+	Reflector string             `json:"reflector"`
+	Entries   map[string][]Entry `json:"entries"`
+}
+
+// BuildCatalogue computes the characteristic of every rotor order and start
+// position.
+func BuildCatalogue(opts Options) (*Catalogue, error) {
+	if opts.Reflector == "" {
+		opts.Reflector = "B"
+	}
+	orders := opts.RotorOrders
+	if len(orders) == 0 {
+		rotors := opts.Rotors
+		if len(rotors) == 0 {
+			rotors = []string{"I", "II", "III"}
+		}
+		orders = enigma.RotorOrders(rotors, 3)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]map[string][]Entry, len(orders))
+	err := util.Parallel(len(orders), workers, func(i int) error {
+		var err error
+		results[i], err = catalogueOrder(orders[i], opts.Reflector)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalogue{Reflector: opts.Reflector, Entries: map[string][]Entry{}}
+	for i := range orders {
+		for characteristic, entries := range results[i] {
+			c.Entries[characteristic] = append(c.Entries[characteristic], entries...)
+		}
+	}
+	return c, nil
+}
+
+// catalogueOrder computes the characteristic of every start position of one
+// rotor order.
+func catalogueOrder(order []string, reflector string) (map[string][]Entry, error) {
+	scrambler, err := enigma.NewScrambler(enigma.Config{
+		Rotors:    order,
+		Reflector: enigma.ReflectorConfig{Name: reflector},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string][]Entry{}
+	rows := make([][enigma.ALPHABET_SIZE]byte, 6)
+	for position := 0; position < scrambler.Positions(); position++ {
+		scrambler.Rows(rows, position)
+		characteristic, err := CharacteristicOf(permutations(rows))
+		if err != nil {
+			return nil, err
+		}
+
+		key := characteristic.String()
+		entries[key] = append(entries[key], Entry{Rotors: order, Position: scrambler.PositionLetters(position)})
+	}
+	return entries, nil
+}
+
+// permutations returns AD, BE and CF from the scrambler's permutations at the
+// six indicator letters. Each of A to F is its own inverse, so AD sends A(x)
+// to D(x).
+func permutations(rows [][enigma.ALPHABET_SIZE]byte) [3]Permutation {
+	perms := [3]Permutation{}
+	for i := range perms {
+		for x := 0; x < enigma.ALPHABET_SIZE; x++ {
+			perms[i][rows[i][x]] = int(rows[i+3][x])
+		}
+	}
+	return perms
+}
+
+// Lookup returns the settings with the characteristic.
+func (c *Catalogue) Lookup(characteristic Characteristic) []Entry {
+	return c.Entries[characteristic.String()]
+}
+
+// Search works out the characteristic of a day's indicators and looks it up.
+func (c *Catalogue) Search(indicators []string) (Characteristic, []Entry, error) {
+	perms, err := Permutations(indicators)
+	if err != nil {
+		return Characteristic{}, nil, err
+	}
+	characteristic, err := CharacteristicOf(perms)
+	if err != nil {
+		return Characteristic{}, nil, err
+	}
+	return characteristic, c.Lookup(characteristic), nil
+}
+
+// Len returns the number of different characteristics in the catalogue.
+func (c *Catalogue) Len() int {
+	return len(c.Entries)
+}
+
+// Save writes the catalogue as JSON, so it only has to be built once.
+func (c *Catalogue) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c)
+}
+
+// LoadCatalogue reads a catalogue written by Save.
+func LoadCatalogue(r io.Reader) (*Catalogue, error) {
+	c := &Catalogue{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	if c.Entries == nil {
+		return nil, fmt.Errorf("invalid catalogue: no entries")
+	}
+	return c, nil
+}
@@ -0,0 +1,112 @@
+package rejewski
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// dailyIndicators encrypts a doubled message key for every letter at the
+// daily key, as a day's traffic would have.
+func dailyIndicators(t *testing.T, key enigma.Config) []string {
+	em, err := key.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indicators := []string{}
+	for k := 0; k < 26; k++ {
+		messageKey := string([]rune{rune('A' + k), rune('A' + (k+5)%26), rune('A' + (k+17)%26)})
+		em.Reset()
+		indicator, err := em.DecryptString(messageKey + messageKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		indicators = append(indicators, indicator)
+	}
+	return indicators
+}
+
+func TestCatalogue(t *testing.T) {
+	key := enigma.Config{
+		Rotors:         []string{"II", "I", "III"},
+		RotorPositions: "KDC",
+		Reflector:      enigma.ReflectorConfig{Name: "B"},
+		Plugboard:      enigma.PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ"}},
+	}
+	indicators := dailyIndicators(t, key)
+
+	perms, err := Permutations(indicators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	characteristic, err := CharacteristicOf(perms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, lengths := range characteristic {
+		// the cycles of AD, BE and CF come in pairs
+		for j := 0; j < len(lengths); j += 2 {
+			if lengths[j] != lengths[j+1] {
+				t.Errorf("permutation %d: unpaired cycle lengths %v", i, lengths)
+			}
+		}
+	}
+
+	c, err := BuildCatalogue(Options{RotorOrders: [][]string{{"II", "I", "III"}, {"I", "II", "III"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := c.Lookup(characteristic)
+	if _, found, err := c.Search(indicators); err != nil || !slices.EqualFunc(found, entries, sameEntry) {
+		t.Fatalf("expected Search to find the same entries as Lookup, got %v, %v", found, err)
+	}
+	if !slices.ContainsFunc(entries, func(e Entry) bool {
+		return slices.Equal(e.Rotors, key.Rotors) && e.Position == key.RotorPositions
+	}) {
+		t.Fatalf("expected %s in the entries for %s, got %v", key.RotorPositions, characteristic, entries)
+	}
+
+	// most characteristics are rare enough to narrow the key down
+	if c.Len() < 1000 {
+		t.Errorf("expected many different characteristics, got %d", c.Len())
+	}
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCatalogue(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(loaded.Lookup(characteristic), entries, sameEntry) {
+		t.Error("the loaded catalogue does not match the saved one")
+	}
+}
+
+func TestCharacteristicOf_Incomplete(t *testing.T) {
+	perms, err := Permutations([]string{"ABCDEF"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CharacteristicOf(perms); err == nil {
+		t.Error("expected error for incomplete permutations, got nil")
+	}
+}
+
+func TestLoadCatalogue_Invalid(t *testing.T) {
+	for _, data := range []string{"", "{}", "not json"} {
+		if _, err := LoadCatalogue(strings.NewReader(data)); err == nil {
+			t.Errorf("%q: expected error, got nil", data)
+		}
+	}
+}
+
+func sameEntry(a, b Entry) bool {
+	return a.String() == b.String()
+}
@@ -0,0 +1,173 @@
+// Package rejewski implements the characteristic method Marian Rejewski used
+// to find Enigma daily keys from 1933 to 1938.
+//
+// Under the procedure of the time every message of the day started with its
+// three letter message key typed twice, encrypted at the same daily start
+// position. The first and fourth letters of all the indicators together give
+// the permutation AD, the second and fifth BE and the third and sixth CF. The
+// lengths of the cycles of those permutations do not depend on the plugboard,
+// only on the rotor order and start position, so a catalogue of them built in
+// advance narrows the daily key down to a handful of settings.
+package rejewski
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Permutation maps each letter index to another, -1 where it is not known.
+type Permutation [26]int
+
+// Permutations derives AD, BE and CF from a day's six letter indicators. An
+// indicator xyzuvw sends x to u in AD, y to v in BE and z to w in CF. Spaces
+// are ignored. Letters not seen in any indicator are left unknown.
+func Permutations(indicators []string) ([3]Permutation, error) {
+	perms := [3]Permutation{}
+	for i := range perms {
+		for j := range perms[i] {
+			perms[i][j] = -1
+		}
+	}
+
+	for _, indicator := range indicators {
+		indicator = strings.ToUpper(strings.ReplaceAll(indicator, " ", ""))
+		if len(indicator) != 6 {
+			return perms, fmt.Errorf("indicator %s must be 6 letters long", indicator)
+		}
+		for _, r := range indicator {
+			if r < 'A' || r > 'Z' {
+				return perms, fmt.Errorf("invalid letter: %c", r)
+			}
+		}
+
+		for i := range perms {
+			from, to := int(indicator[i]-'A'), int(indicator[i+3]-'A')
+			if perms[i][from] != -1 && perms[i][from] != to {
+				return perms, fmt.Errorf("indicator %s contradicts an earlier one: %c goes to both %c and %c",
+					indicator, rune('A'+from), rune('A'+perms[i][from]), rune('A'+to))
+			}
+			perms[i][from] = to
+		}
+	}
+
+	return perms, nil
+}
+
+// Complete reports whether every letter of the permutation is known.
+func (p Permutation) Complete() bool {
+	return !slices.Contains(p[:], -1)
+}
+
+// Cycles returns the cycles of a complete permutation, each starting with its
+// lowest letter, ordered by that letter.
+func (p Permutation) Cycles() ([][]int, error) {
+	if !p.Complete() {
+		return nil, fmt.Errorf("the permutation is not complete")
+	}
+
+	cycles := [][]int{}
+	seen := [26]bool{}
+	for start := range p {
+		if seen[start] {
+			continue
+		}
+		cycle := []int{}
+		for l := start; !seen[l]; l = p[l] {
+			seen[l] = true
+			cycle = append(cycle, l)
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles, nil
+}
+
+// String writes the permutation in cycle notation, such as (AFK)(BZ)..., or
+// as the letters it sends A to Z to, with ? for unknown ones, when it is not
+// complete.
+func (p Permutation) String() string {
+	cycles, err := p.Cycles()
+	if err != nil {
+		var b strings.Builder
+		for _, l := range p {
+			if l == -1 {
+				b.WriteRune('?')
+				continue
+			}
+			b.WriteRune(rune('A' + l))
+		}
+		return b.String()
+	}
+
+	var b strings.Builder
+	for _, cycle := range cycles {
+		b.WriteRune('(')
+		for _, l := range cycle {
+			b.WriteRune(rune('A' + l))
+		}
+		b.WriteRune(')')
+	}
+	return b.String()
+}
+
+// Characteristic is the cycle structure of AD, BE and CF: the lengths of the
+// cycles of each, longest first. Cycles of a product of two reflections come
+// in pairs of the same length, such as 13 13 or 10 10 2 2 1 1.
+type Characteristic [3][]int
+
+// CharacteristicOf returns the characteristic of complete permutations.
+func CharacteristicOf(perms [3]Permutation) (Characteristic, error) {
+	c := Characteristic{}
+	names := []string{"AD", "BE", "CF"}
+	for i, p := range perms {
+		cycles, err := p.Cycles()
+		if err != nil {
+			return c, fmt.Errorf("%s is not complete, more indicators are needed", names[i])
+		}
+		for _, cycle := range cycles {
+			c[i] = append(c[i], len(cycle))
+		}
+		slices.SortFunc(c[i], func(a, b int) int { return b - a })
+	}
+	return c, nil
+}
+
+// ParseCharacteristic reads a characteristic written by String.
+func ParseCharacteristic(s string) (Characteristic, error) {
+	c := Characteristic{}
+	parts := strings.Split(s, "|")
+	if len(parts) != 3 {
+		return c, fmt.Errorf("invalid characteristic %q: expected 3 parts separated by |", s)
+	}
+	for i, part := range parts {
+		total := 0
+		for _, field := range strings.Fields(part) {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 {
+				return c, fmt.Errorf("invalid characteristic %q: bad cycle length %s", s, field)
+			}
+			c[i] = append(c[i], n)
+			total += n
+		}
+		if total != 26 {
+			return c, fmt.Errorf("invalid characteristic %q: cycle lengths must add up to 26", s)
+		}
+		slices.SortFunc(c[i], func(a, b int) int { return b - a })
+	}
+	return c, nil
+}
+
+// String writes the characteristic as the cycle lengths of AD, BE and CF
+// separated by bars, such as "13 13 | 10 10 2 2 1 1 | 9 9 4 4".
+func (c Characteristic) String() string {
+	parts := make([]string, len(c))
+	for i, lengths := range c {
+		fields := make([]string, len(lengths))
+		for j, n := range lengths {
+			fields[j] = strconv.Itoa(n)
+		}
+		parts[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,107 @@
+package rejewski
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPermutations(t *testing.T) {
+	perms, err := Permutations([]string{"ABCDEF", "dmq vbn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A goes to D and D to V in AD
+	if perms[0][0] != 3 || perms[0][3] != 21 {
+		t.Errorf("unexpected AD: %s", perms[0])
+	}
+	if perms[1][1] != 4 || perms[1][12] != 1 {
+		t.Errorf("unexpected BE: %s", perms[1])
+	}
+	if perms[2][2] != 5 || perms[2][16] != 13 {
+		t.Errorf("unexpected CF: %s", perms[2])
+	}
+	if perms[0].Complete() {
+		t.Error("AD should not be complete")
+	}
+	if got := perms[0].String(); got != "D??V??????????????????????" {
+		t.Errorf("unexpected incomplete AD: %s", got)
+	}
+}
+
+func TestPermutations_Invalid(t *testing.T) {
+	tests := [][]string{
+		{"ABCDE"},
+		{"ABCDEFG"},
+		{"ABC1EF"},
+		// A goes to D and then to E
+		{"ABCDEF", "AXYEVW"},
+	}
+
+	for _, indicators := range tests {
+		if _, err := Permutations(indicators); err == nil {
+			t.Errorf("%v: expected error, got nil", indicators)
+		}
+	}
+}
+
+func TestCycles(t *testing.T) {
+	p := Permutation{}
+	for i := range p {
+		p[i] = i
+	}
+	// (ABC)(DE), everything else fixed
+	p[0], p[1], p[2] = 1, 2, 0
+	p[3], p[4] = 4, 3
+
+	cycles, err := p.Cycles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cycles) != 23 {
+		t.Fatalf("expected 23 cycles, got %d", len(cycles))
+	}
+	if !slices.Equal(cycles[0], []int{0, 1, 2}) || !slices.Equal(cycles[1], []int{3, 4}) {
+		t.Errorf("unexpected cycles: %v", cycles[:2])
+	}
+	if got := p.String(); got != "(ABC)(DE)(F)(G)(H)(I)(J)(K)(L)(M)(N)(O)(P)(Q)(R)(S)(T)(U)(V)(W)(X)(Y)(Z)" {
+		t.Errorf("unexpected cycle notation: %s", got)
+	}
+
+	p[0] = -1
+	if _, err := p.Cycles(); err == nil {
+		t.Error("expected error for an incomplete permutation, got nil")
+	}
+}
+
+func TestParseCharacteristic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"13 13 | 10 10 2 2 1 1 | 9 9 4 4", "13 13 | 10 10 2 2 1 1 | 9 9 4 4", true},
+		{"1 1 2 2 10 10|13 13|4 4 9 9", "10 10 2 2 1 1 | 13 13 | 9 9 4 4", true},
+		{"13 13 | 13 13", "", false},
+		{"13 12 | 13 13 | 13 13", "", false},
+		{"13 13 | 13 x | 13 13", "", false},
+		{"26 0 | 13 13 | 13 13", "", false},
+	}
+
+	for _, test := range tests {
+		c, err := ParseCharacteristic(test.input)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected error, got nil", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+			continue
+		}
+		if c.String() != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.input, test.expected, c)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+)
+
+// ChiSquared compares the letter frequencies of a text with those of a
+// language.
+type ChiSquared struct {
+	frequencies [26]float64
+}
+
+// NewChiSquared builds the comparison from a table of single letters.
+func NewChiSquared(unigrams *NGrams) (*ChiSquared, error) {
+	if unigrams == nil || unigrams.n != 1 {
+		return nil, fmt.Errorf("chi-squared needs a table of single letters")
+	}
+	c := &ChiSquared{}
+	for i, logProb := range unigrams.logProbs {
+		c.frequencies[i] = math.Pow(10, logProb)
+	}
+	return c, nil
+}
+
+// Statistic returns the chi-squared statistic of the letter counts of the
+// text against the counts expected in the language. It is 0 for a perfect
+// match and grows as the text looks less like the language.
+func (c *ChiSquared) Statistic(text []byte) float64 {
+	counts, total := letterCounts(text)
+	statistic := 0.0
+	for i, count := range counts {
+		expected := c.frequencies[i] * float64(total)
+		if expected == 0 {
+			continue
+		}
+		d := float64(count) - expected
+		statistic += d * d / expected
+	}
+	return statistic
+}
+
+// Score returns the negated statistic, so that higher is better like every
+// other scorer.
+func (c *ChiSquared) Score(text []byte) float64 {
+	return -c.Statistic(text)
+}
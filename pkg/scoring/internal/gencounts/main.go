@@ -0,0 +1,124 @@
+// Command gencounts counts the n-grams of the corpora in corpus/ and writes
+// the count files embedded by the scoring package to data/. It is run with go
+// generate from the scoring package directory.
+//
+// Each language is read from the .txt files in corpus/<language>/, which are
+// checked in with their sources listed in corpus/README.md, so the tables are
+// the same wherever they are generated.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languages maps each corpus to whether full stops are keyed as X, as was
+// done in German Enigma traffic.
+var languages = map[string]bool{
+	"german":  true,
+	"english": false,
+}
+
+func main() {
+	min := flag.Int("min", 1, "Leave out quadgrams seen fewer times than this, they score as unseen")
+	flag.Parse()
+
+	for language, stops := range languages {
+		files, err := filepath.Glob(filepath.Join("corpus", language, "*.txt"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(files) == 0 {
+			log.Fatalf("no corpus for %s", language)
+		}
+		sort.Strings(files)
+
+		// n-grams are counted within each file, so none spans two texts
+		texts := []string{}
+		for _, file := range files {
+			corpus, err := os.ReadFile(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			texts = append(texts, letters(string(corpus), stops))
+		}
+
+		for n := 1; n <= 4; n++ {
+			least := 1
+			if n == 4 {
+				least = *min
+			}
+			name := filepath.Join("data", fmt.Sprintf("%s-%dgrams.txt", language, n))
+			if err := writeCounts(name, language, texts, n, least); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// letters returns the letters of the text in upper case, spelling out the
+// umlauts, ligatures and sharp s the way they were keyed on the Enigma, and
+// optionally the full stops as X.
+func letters(text string, stops bool) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(text) {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case r == 'Ä' || r == 'Æ':
+			b.WriteString("AE")
+		case r == 'Ö' || r == 'Œ':
+			b.WriteString("OE")
+		case r == 'Ü':
+			b.WriteString("UE")
+		case r == 'ß':
+			b.WriteString("SS")
+		case r == '.' && stops:
+			b.WriteRune('X')
+		}
+	}
+	return b.String()
+}
+
+// writeCounts writes every n-gram of the texts seen at least least times with
+// its count, most common first.
+func writeCounts(name, language string, texts []string, n, least int) error {
+	counts := map[string]int{}
+	for _, text := range texts {
+		for i := 0; i+n <= len(text); i++ {
+			counts[text[i:i+n]]++
+		}
+	}
+
+	ngrams := make([]string, 0, len(counts))
+	for ngram, count := range counts {
+		if count >= least {
+			ngrams = append(ngrams, ngram)
+		}
+	}
+	sort.Slice(ngrams, func(i, j int) bool {
+		if counts[ngrams[i]] != counts[ngrams[j]] {
+			return counts[ngrams[i]] > counts[ngrams[j]]
+		}
+		return ngrams[i] < ngrams[j]
+	})
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# Code generated by gencounts from corpus/%s. DO NOT EDIT.\n", language)
+	for _, ngram := range ngrams {
+		fmt.Fprintf(w, "%s %d\n", ngram, counts[ngram])
+	}
+	return w.Flush()
+}
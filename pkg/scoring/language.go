@@ -0,0 +1,164 @@
+package scoring
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// The languages with embedded frequency tables.
+const (
+	LANGUAGE_GERMAN  = "german"
+	LANGUAGE_ENGLISH = "english"
+)
+
+// data holds the count files generated by internal/gencounts from the
+// corpora in corpus/, whose sources are listed in corpus/README.md.
+//
+//go:embed data
+var data embed.FS
+
+// Language holds the n-gram tables of a language. Any table may be missing
+// from a language built with NewLanguage.
+type Language struct {
+	Name      string
+	Unigrams  *NGrams
+	Bigrams   *NGrams
+	Trigrams  *NGrams
+	Quadgrams *NGrams
+}
+
+// NewLanguage builds a language from n-gram tables of any length. A table
+// replaces an earlier one of the same length, so a built in language can be
+// given custom tables:
+//
+//	trigrams, err := scoring.LoadNGramsFile("trigrams.txt")
+//	german := scoring.German()
+//	l, err := scoring.NewLanguage("german", german.Unigrams, german.Bigrams, trigrams)
+func NewLanguage(name string, tables ...*NGrams) (*Language, error) {
+	l := &Language{Name: name}
+	for _, g := range tables {
+		if g == nil {
+			return nil, fmt.Errorf("missing n-gram table")
+		}
+		switch g.n {
+		case 1:
+			l.Unigrams = g
+		case 2:
+			l.Bigrams = g
+		case 3:
+			l.Trigrams = g
+		case 4:
+			l.Quadgrams = g
+		default:
+			return nil, fmt.Errorf("invalid n-gram length: %d", g.n)
+		}
+	}
+	return l, nil
+}
+
+// NGrams returns the table of n letter sequences, or an error if the language
+// does not have one.
+func (l *Language) NGrams(n int) (*NGrams, error) {
+	var g *NGrams
+	switch n {
+	case 1:
+		g = l.Unigrams
+	case 2:
+		g = l.Bigrams
+	case 3:
+		g = l.Trigrams
+	case 4:
+		g = l.Quadgrams
+	}
+	if g == nil {
+		return nil, fmt.Errorf("language %s has no table of %d letter n-grams", l.Name, n)
+	}
+	return g, nil
+}
+
+// Digest returns a SHA-256 of the language's tables, so a run scored with
+// them can tell when it is resumed with different ones. The name is left out.
+func (l *Language) Digest() string {
+	h := sha256.New()
+	for _, g := range []*NGrams{l.Unigrams, l.Bigrams, l.Trigrams, l.Quadgrams} {
+		if g == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{byte(g.n)})
+		binary.Write(h, binary.LittleEndian, g.logProbs)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChiSquared returns the unigram chi-squared scorer of the language.
+func (l *Language) ChiSquared() (*ChiSquared, error) {
+	unigrams, err := l.NGrams(1)
+	if err != nil {
+		return nil, err
+	}
+	return NewChiSquared(unigrams)
+}
+
+var (
+	germanOnce, englishOnce sync.Once
+	german, english         *Language
+)
+
+// German returns the frequencies of German, counted from the prose and
+// traffic in corpus/german with full stops keyed as X like in Enigma traffic.
+func German() *Language {
+	germanOnce.Do(func() {
+		german = mustLoadLanguage(LANGUAGE_GERMAN)
+	})
+	return german
+}
+
+// English returns the frequencies of English, counted from Newton's Opticks
+// and the traffic in corpus/english.
+func English() *Language {
+	englishOnce.Do(func() {
+		english = mustLoadLanguage(LANGUAGE_ENGLISH)
+	})
+	return english
+}
+
+// LanguageFromSelection returns one of the embedded languages by name.
+func LanguageFromSelection(selection string) (*Language, error) {
+	switch strings.ToLower(selection) {
+	case LANGUAGE_GERMAN:
+		return German(), nil
+	case LANGUAGE_ENGLISH:
+		return English(), nil
+	default:
+		return nil, fmt.Errorf("invalid language: %s", selection)
+	}
+}
+
+// mustLoadLanguage loads the embedded tables of a language, which are
+// generated and checked by the tests, so an error is a bug.
+func mustLoadLanguage(name string) *Language {
+	tables := make([]*NGrams, 4)
+	for n := 1; n <= 4; n++ {
+		f, err := data.Open(fmt.Sprintf("data/%s-%dgrams.txt", name, n))
+		if err != nil {
+			panic(err)
+		}
+		tables[n-1], err = LoadNGrams(f)
+		f.Close()
+		if err != nil {
+			panic(fmt.Sprintf("%s %d-grams: %v", name, n, err))
+		}
+	}
+
+	l, err := NewLanguage(name, tables...)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
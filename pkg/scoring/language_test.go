@@ -0,0 +1,164 @@
+package scoring
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const (
+	germanSample  = "DERFEINDGREIFTIMMORGENGRAUENANUNDDIEEIGENENTRUPPENHALTENDIESTELLUNGXVERSTAERKUNGWIRDERBETEN"
+	englishSample = "THEENEMYATTACKEDATDAWNANDOURTROOPSAREHOLDINGTHEIRPOSITIONSREINFORCEMENTSAREREQUESTEDATONCE"
+	randomSample  = "QXJVZKWPYQMBFXJZKQVWPYXGJQZKVBXMWQJFZPYKVXQJZMBWKPXQYJVZFKWQXMBJYPZLTRDCNHSGEAOUIRTNLSDHC"
+)
+
+func TestEmbeddedLanguages(t *testing.T) {
+	for _, name := range []string{LANGUAGE_GERMAN, LANGUAGE_ENGLISH} {
+		l, err := LanguageFromSelection(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 1; n <= 4; n++ {
+			g, err := l.NGrams(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.N() != n {
+				t.Errorf("%s: expected %d letter n-grams, got %d", name, n, g.N())
+			}
+		}
+	}
+
+	if _, err := LanguageFromSelection("klingon"); err == nil {
+		t.Error("expected error for an unknown language, got nil")
+	}
+}
+
+func TestLanguageScores(t *testing.T) {
+	tests := []struct {
+		language *Language
+		native   string
+		foreign  string
+	}{
+		{German(), germanSample, englishSample},
+		{English(), englishSample, germanSample},
+	}
+
+	for _, test := range tests {
+		chiSquared, err := test.language.ChiSquared()
+		if err != nil {
+			t.Fatal(err)
+		}
+		scorers := map[string]Scorer{
+			"chi-squared": chiSquared,
+			"bigrams":     test.language.Bigrams,
+			"trigrams":    test.language.Trigrams,
+			"quadgrams":   test.language.Quadgrams,
+		}
+		for name, s := range scorers {
+			native := s.Score([]byte(test.native))
+			if foreign := s.Score([]byte(test.foreign)); native <= foreign {
+				t.Errorf("%s %s: expected native text to beat foreign text, got %f and %f", test.language.Name, name, native, foreign)
+			}
+			if random := s.Score([]byte(randomSample)); native <= random {
+				t.Errorf("%s %s: expected native text to beat random letters, got %f and %f", test.language.Name, name, native, random)
+			}
+		}
+	}
+}
+
+// doenitzMessage is the decrypt of the naval signal of 1 May 1945 naming
+// Dönitz as Hitler's successor, garbles included.
+const doenitzMessage = "KRKRALLEXXFOLGENDESISTSOFORTBEKANNTZUGEBENXXICHHABEFOLGELNBEBEFEHLERHALTENXXJANSTERLEDESBISHERIGXNREICHSMARSCHALLSJGOERINGJSETZTDERFUEHRERSIEYHVRRGRZSSADMIRALYALSSEINENNACHFOLGEREINXSCHRIFTLSCHEVOLLMACHTUNTERWEGSXABSOFORTSOLLENSIESAEMTLICHEMASSNAHMENVERFUEGENYDIESICHAUSDERGEGENWAERTIGENLAGEERGEBENXGEZXREICHSLEITEIKKTULPEKKJBORMANNJXX"
+
+func TestLanguageMargins(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := make([]byte, len(doenitzMessage))
+	for i := range random {
+		random[i] = byte('A' + r.Intn(26))
+	}
+
+	for _, l := range []*Language{German(), English()} {
+		chiSquared, err := l.ChiSquared()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the statistic of random letters is many times that of real text
+		native, other := -chiSquared.Score([]byte(doenitzMessage)), -chiSquared.Score(random)
+		if other < 5*native {
+			t.Errorf("%s chi-squared: expected random letters to score at least 5 times worse, got %f and %f", l.Name, native, other)
+		}
+
+		// a table counted from too little text puts most n-grams of real
+		// text at the floor, leaving little between it and random letters
+		for n := 1; n <= 4; n++ {
+			g, err := l.NGrams(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count := float64(len(doenitzMessage) - n + 1)
+			margin := (g.Score([]byte(doenitzMessage)) - g.Score(random)) / count
+			if want := 0.25 * float64(n); margin < want {
+				t.Errorf("%s %d-grams: expected a margin of at least %.2f per n-gram over random letters, got %f", l.Name, n, want, margin)
+			}
+		}
+	}
+}
+
+func TestNewLanguage(t *testing.T) {
+	trigrams, err := NewNGrams(map[string]int{"ABC": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	german := German()
+	l, err := NewLanguage("custom", german.Unigrams, german.Trigrams, trigrams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Trigrams != trigrams {
+		t.Error("expected the later trigram table to replace the earlier one")
+	}
+	if _, err := l.NGrams(2); err == nil {
+		t.Error("expected error for the missing bigram table, got nil")
+	}
+	if _, err := l.ChiSquared(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := NewLanguage("custom", nil); err == nil {
+		t.Error("expected error for a nil table, got nil")
+	}
+	if _, err := (&Language{Name: "empty"}).ChiSquared(); err == nil {
+		t.Error("expected error without a unigram table, got nil")
+	}
+}
+
+func TestLanguage_Digest(t *testing.T) {
+	german := German()
+	if German().Digest() != german.Digest() {
+		t.Error("expected the same tables to give the same digest")
+	}
+	if English().Digest() == german.Digest() {
+		t.Error("expected different tables to give different digests")
+	}
+
+	// the name does not matter, the counts do
+	renamed, err := NewLanguage("renamed", german.Unigrams, german.Bigrams, german.Trigrams, german.Quadgrams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Digest() != german.Digest() {
+		t.Error("expected a renamed language to keep its digest")
+	}
+	trigrams, err := NewNGrams(map[string]int{"EIN": 2, "DER": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := NewLanguage(german.Name, german.Unigrams, german.Bigrams, trigrams, german.Quadgrams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Digest() == german.Digest() {
+		t.Error("expected a different trigram table to change the digest")
+	}
+}
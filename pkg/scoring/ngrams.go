@@ -0,0 +1,161 @@
+package scoring
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+)
+
+// NGrams holds the log probability of every sequence of N letters, counted
+// from a body of text in some language.
+type NGrams struct {
+	n        int
+	logProbs []float64
+}
+
+// NewNGrams builds the table from the number of times each n-gram was seen.
+// All n-grams must have the same length, from 1 to 4 letters. N-grams that
+// were never seen get a small floor probability rather than zero, so a single
+// unusual sequence does not rule out an otherwise good decrypt.
+func NewNGrams(counts map[string]int) (*NGrams, error) {
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("no n-gram counts")
+	}
+
+	n := 0
+	for ngram := range counts {
+		n = len(ngram)
+		break
+	}
+	if n < 1 || n > 4 {
+		return nil, fmt.Errorf("n-grams must be 1 to 4 letters long, got %d", n)
+	}
+
+	values := make([]float64, util.Pow26(n))
+	total := 0.0
+	for ngram, count := range counts {
+		if len(ngram) != n {
+			return nil, fmt.Errorf("n-gram %s is not %d letters long", ngram, n)
+		}
+		index, ok := ngramIndex(ngram)
+		if !ok {
+			return nil, fmt.Errorf("invalid n-gram: %s", ngram)
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("invalid count for n-gram %s: %d", ngram, count)
+		}
+		values[index] += float64(count)
+		total += float64(count)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no n-gram counts")
+	}
+
+	floor := math.Log10(0.01 / total)
+	for i, count := range values {
+		if count == 0 {
+			values[i] = floor
+			continue
+		}
+		values[i] = math.Log10(count / total)
+	}
+	return &NGrams{n: n, logProbs: values}, nil
+}
+
+// LoadNGrams reads a count file, with an n-gram and the number of times it
+// was seen on each line:
+//
+//	TION 13168375
+//	NTHE 11234972
+//
+// Blank lines and lines starting with # are skipped.
+func LoadNGrams(r io.Reader) (*NGrams, error) {
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected an n-gram and a count, got %q", line, text)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid count: %s", line, fields[1])
+		}
+		counts[strings.ToUpper(fields[0])] += count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewNGrams(counts)
+}
+
+// LoadNGramsFile reads a count file from disk, see LoadNGrams.
+func LoadNGramsFile(name string) (*NGrams, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g, err := LoadNGrams(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return g, nil
+}
+
+// N returns the length of the n-grams.
+func (g *NGrams) N() int {
+	return g.n
+}
+
+// LogProb returns the base 10 log probability of an n-gram.
+func (g *NGrams) LogProb(ngram string) float64 {
+	index, ok := ngramIndex(ngram)
+	if !ok || len(ngram) != g.n {
+		return math.Inf(-1)
+	}
+	return g.logProbs[index]
+}
+
+// Score returns the sum of the log probabilities of every n-gram in the text.
+func (g *NGrams) Score(text []byte) float64 {
+	score := 0.0
+	span := len(g.logProbs) / 26
+	index, run := 0, 0
+	for _, c := range text {
+		l := letterIndex(c)
+		if l < 0 {
+			index, run = 0, 0
+			continue
+		}
+		// drop the first letter of the last n-gram and add this one
+		index = index%span*26 + l
+		if run++; run >= g.n {
+			score += g.logProbs[index]
+		}
+	}
+	return score
+}
+
+func ngramIndex(ngram string) (int, bool) {
+	index := 0
+	for i := 0; i < len(ngram); i++ {
+		l := letterIndex(ngram[i])
+		if l < 0 {
+			return 0, false
+		}
+		index = index*26 + l
+	}
+	return index, true
+}
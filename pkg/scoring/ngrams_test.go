@@ -0,0 +1,124 @@
+package scoring
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNewNGrams(t *testing.T) {
+	g, err := NewNGrams(map[string]int{"AB": 3, "ba": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.N() != 2 {
+		t.Fatalf("expected 2 letter n-grams, got %d", g.N())
+	}
+
+	tests := []struct {
+		ngram    string
+		expected float64
+	}{
+		{"AB", math.Log10(0.75)},
+		{"BA", math.Log10(0.25)},
+		{"ba", math.Log10(0.25)},
+		// never seen
+		{"CC", math.Log10(0.01 / 4)},
+		// wrong length or not letters
+		{"ABC", math.Inf(-1)},
+		{"A1", math.Inf(-1)},
+	}
+
+	for _, test := range tests {
+		if got := g.LogProb(test.ngram); got != test.expected && math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("%s: expected %f, got %f", test.ngram, test.expected, got)
+		}
+	}
+}
+
+func TestNewNGrams_Invalid(t *testing.T) {
+	tests := []map[string]int{
+		{},
+		{"AB": 0},
+		{"AB": 1, "ABC": 1},
+		{"ABCDE": 1},
+		{"A1": 1},
+		{"AB": -1},
+	}
+
+	for _, counts := range tests {
+		if _, err := NewNGrams(counts); err == nil {
+			t.Errorf("%v: expected error, got nil", counts)
+		}
+	}
+}
+
+func TestNGramsScore(t *testing.T) {
+	g, err := NewNGrams(map[string]int{"ABC": 2, "BCD": 1, "XYZ": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text     string
+		expected float64
+	}{
+		{"", 0},
+		{"AB", 0},
+		{"ABC", g.LogProb("ABC")},
+		{"ABCD", g.LogProb("ABC") + g.LogProb("BCD")},
+		{"abcd", g.LogProb("ABC") + g.LogProb("BCD")},
+		// anything but a letter breaks up the n-grams
+		{"AB CD", 0},
+		{"ABC XYZ", g.LogProb("ABC") + g.LogProb("XYZ")},
+		{"ZABC", g.LogProb("ZAB") + g.LogProb("ABC")},
+	}
+
+	for _, test := range tests {
+		if got := g.Score([]byte(test.text)); math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("%q: expected %f, got %f", test.text, test.expected, got)
+		}
+	}
+}
+
+func TestLoadNGrams(t *testing.T) {
+	file := `# counts
+TION 3
+
+nthe 1
+TION 1
+`
+	g, err := LoadNGrams(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.N() != 4 {
+		t.Fatalf("expected 4 letter n-grams, got %d", g.N())
+	}
+	if got, expected := g.LogProb("TION"), math.Log10(0.8); math.Abs(got-expected) > 1e-9 {
+		t.Errorf("expected %f, got %f", expected, got)
+	}
+}
+
+func TestLoadNGrams_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"# only a comment\n",
+		"TION\n",
+		"TION 3 4\n",
+		"TION many\n",
+		"TION 3\nTHE 2\n",
+	}
+
+	for _, file := range tests {
+		if _, err := LoadNGrams(strings.NewReader(file)); err == nil {
+			t.Errorf("%q: expected error, got nil", file)
+		}
+	}
+}
+
+func TestLoadNGramsFile_Missing(t *testing.T) {
+	if _, err := LoadNGramsFile("testdata/missing.txt"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,66 @@
+// Package scoring rates how much a candidate decrypt looks like natural
+// language. Attacks on the Enigma try a huge number of keys, and a scorer is
+// the fitness function that tells the promising ones apart.
+//
+// Texts are given as bytes of the letters A to Z. Lower case letters count as
+// upper case, anything else is skipped and breaks up the n-grams around it.
+package scoring
+
+//go:generate go run ./internal/gencounts -min 2
+
+// Scorer rates a text, higher scores look more like language.
+type Scorer interface {
+	Score(text []byte) float64
+}
+
+// ScorerFunc lets an ordinary function be used as a Scorer.
+type ScorerFunc func(text []byte) float64
+
+func (f ScorerFunc) Score(text []byte) float64 {
+	return f(text)
+}
+
+// IoC scores a text by its index of coincidence.
+var IoC Scorer = ScorerFunc(IndexOfCoincidence)
+
+// IndexOfCoincidence returns the chance that two letters picked from the text
+// are the same. Random text is close to 1/26 or 0.038, German is around 0.076
+// and English around 0.066. It does not depend on which letter is which, so it
+// can tell a nearly right Enigma key from a wrong one before the plugboard is
+// known.
+func IndexOfCoincidence(text []byte) float64 {
+	counts, total := letterCounts(text)
+	if total < 2 {
+		return 0
+	}
+	sum := 0
+	for _, c := range counts {
+		sum += c * (c - 1)
+	}
+	return float64(sum) / float64(total*(total-1))
+}
+
+func letterCounts(text []byte) ([26]int, int) {
+	counts := [26]int{}
+	total := 0
+	for _, c := range text {
+		if l := letterIndex(c); l >= 0 {
+			counts[l]++
+			total++
+		}
+	}
+	return counts, total
+}
+
+// letterIndex returns the index of the letter from 0 to 25, or -1 if it is not
+// a letter.
+func letterIndex(c byte) int {
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A')
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a')
+	default:
+		return -1
+	}
+}
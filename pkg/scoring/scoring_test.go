@@ -0,0 +1,40 @@
+package scoring
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIndexOfCoincidence(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected float64
+	}{
+		{"", 0},
+		{"A", 0},
+		{"AA", 1},
+		{"AB", 0},
+		{"AABB", 2.0 / 6},
+		{"aa bb", 2.0 / 6},
+		{strings.Repeat("ABCDEFGHIJKLMNOPQRSTUVWXYZ", 2), 26 * 2.0 / (52 * 51)},
+	}
+
+	for _, test := range tests {
+		if got := IndexOfCoincidence([]byte(test.text)); math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("%q: expected %f, got %f", test.text, test.expected, got)
+		}
+		if got := IoC.Score([]byte(test.text)); math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("%q: expected IoC score %f, got %f", test.text, test.expected, got)
+		}
+	}
+}
+
+func TestScorerFunc(t *testing.T) {
+	var s Scorer = ScorerFunc(func(text []byte) float64 {
+		return float64(len(text))
+	})
+	if got := s.Score([]byte("ABC")); got != 3 {
+		t.Errorf("expected 3, got %f", got)
+	}
+}
@@ -0,0 +1,499 @@
+// Package search sweeps a part of the Enigma keyspace, every start position
+// of a set of rotor orders and ring settings, on a pool of workers. Each
+// trial is rated by a scoring callback, and the best settings are streamed
+// back as they are found.
+//
+// The sweep is split into shards of one rotor order, ring setting and left
+// rotor position. Each shard runs on its own clone of a machine, so the
+// scoring callback is free to encrypt with the machine it is given. The
+// shards finished and the best results so far can be saved as a checkpoint,
+// and a sweep resumed from one.
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+)
+
+// ScoreFunc rates a trial. The machine is set to the rotor order, ring
+// settings and start position of the trial, and belongs to the calling worker
+// until the function returns. Higher scores are better.
+type ScoreFunc func(em *enigma.EnigmaMachine) float64
+
+// Options describes the keyspace to sweep and how to rate it.
+type Options struct {
+	// Machine holds the settings shared by every trial, such as the model,
+	// reflector and plugboard. Its rotors, positions and ring settings are
+	// ignored. The reflector defaults to B.
+	Machine enigma.Config
+	// RotorOrders are the rotor orders to try, left to right. When empty,
+	// every order of three of Rotors is tried.
+	RotorOrders [][]string
+	// Rotors to build the orders from, defaults to I to V.
+	Rotors []string
+	// RingSettings to try with every rotor order, such as "AAA". Defaults to
+	// A for every rotor.
+	RingSettings []string
+	// Score rates each trial.
+	Score ScoreFunc
+	// Top is the number of best results kept, defaults to 10.
+	Top int
+	// Workers is the number of goroutines used, defaults to the number of
+	// CPUs.
+	Workers int
+	// Progress, when set, is called every ProgressInterval while the sweep
+	// runs, and once more when it ends.
+	Progress func(Progress)
+	// ProgressInterval defaults to one second.
+	ProgressInterval time.Duration
+	// Seed, when not 0, shuffles the order the shards are visited in, so a
+	// sweep stopped early has tried a spread of the keyspace. The same seed
+	// always gives the same order.
+	Seed int64
+	// Checkpoint, when set, is called with the state of the sweep every
+	// CheckpointInterval, and once more when it ends.
+	Checkpoint func(Checkpoint)
+	// CheckpointInterval defaults to one minute.
+	CheckpointInterval time.Duration
+	// Resume continues the sweep from a checkpoint taken with the same
+	// options and scoring. The seed of the checkpoint replaces Seed.
+	Resume *Checkpoint
+}
+
+// Result is a trial that made it into the best results found so far.
+type Result struct {
+	Config enigma.Config `json:"config"`
+	Score  float64       `json:"score"`
+	// Rank is the place of the result among those found so far when it was
+	// sent, 1 being the best. Later results can push it down or out.
+	Rank int `json:"rank"`
+}
+
+// Checkpoint is the state of a sweep, written out so a long run can be
+// stopped and resumed. Only shards that were finished are recorded, so a
+// resumed sweep gives the same results as one that was never stopped.
+type Checkpoint struct {
+	// Keyspace identifies the options the sweep was started with.
+	Keyspace string `json:"keyspace"`
+	Seed     int64  `json:"seed"`
+	// Cursor is the number of shards, in the order they are visited, that
+	// are all done.
+	Cursor int `json:"cursor"`
+	// Done lists the shards past the cursor that are done.
+	Done []int    `json:"done,omitempty"`
+	Best []Result `json:"best"`
+}
+
+// Progress reports how far a sweep has got.
+type Progress struct {
+	Done    int64
+	Total   int64
+	Elapsed time.Duration
+	// Remaining is the estimated time left, from the rate so far.
+	Remaining time.Duration
+}
+
+// Fraction returns the part of the sweep done, from 0 to 1.
+func (p Progress) Fraction() float64 {
+	if p.Total == 0 {
+		return 1
+	}
+	return float64(p.Done) / float64(p.Total)
+}
+
+// shard is one rotor order and ring setting with the left rotor at a fixed
+// position.
+type shard struct {
+	order int
+	rings string
+	left  int
+}
+
+// Search starts sweeping the keyspace and returns a channel of results. A
+// result is sent whenever a trial makes it into the best Top found so far,
+// so the stream gets better as the sweep goes on, and the channel is closed
+// when the sweep ends or the context is done. The channel must be read until
+// it is closed. Errors in the options are returned before anything starts.
+func Search(ctx context.Context, opts Options) (<-chan Result, error) {
+	if opts.Score == nil {
+		return nil, fmt.Errorf("a scoring function is needed")
+	}
+	if opts.Machine.Reflector.Name == "" {
+		opts.Machine.Reflector.Name = "B"
+	}
+	orders := opts.RotorOrders
+	if len(orders) == 0 {
+		rotors := opts.Rotors
+		if len(rotors) == 0 {
+			rotors = []string{"I", "II", "III", "IV", "V"}
+		}
+		orders = enigma.RotorOrders(rotors, 3)
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("no rotor orders to search")
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = time.Second
+	}
+
+	if opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = time.Minute
+	}
+
+	// a machine for each rotor order, cloned for every shard
+	machines := make([]*enigma.EnigmaMachine, len(orders))
+	shards := []shard{}
+	total := int64(0)
+	for i, order := range orders {
+		config := opts.Machine
+		config.Rotors = order
+		config.RotorPositions = ""
+		config.RotorRingSettings = ""
+		em, err := config.Build()
+		if err != nil {
+			return nil, fmt.Errorf("rotor order %s: %w", strings.Join(order, " "), err)
+		}
+		machines[i] = em
+
+		rings := opts.RingSettings
+		if len(rings) == 0 {
+			rings = []string{strings.Repeat("A", len(order))}
+		}
+		for _, r := range rings {
+			if err := em.Clone().SetRotorRingSettings(strings.Split(r, "")); err != nil {
+				return nil, fmt.Errorf("rotor order %s: %w", strings.Join(order, " "), err)
+			}
+			for left := 0; left < enigma.ALPHABET_SIZE; left++ {
+				shards = append(shards, shard{order: i, rings: strings.ToUpper(r), left: left})
+			}
+			total += int64(util.Pow26(len(order)))
+		}
+	}
+
+	s := &sweep{
+		ctx:      ctx,
+		opts:     opts,
+		orders:   orders,
+		machines: machines,
+		shards:   shards,
+		keyspace: keyspace(opts.Machine, orders, opts.RingSettings, opts.Top),
+		seed:     opts.Seed,
+		finished: make([]bool, len(shards)),
+		kept:     ranking{top: opts.Top},
+		found:    make(chan found),
+	}
+	if opts.Resume != nil {
+		if err := s.resume(opts.Resume); err != nil {
+			return nil, err
+		}
+	}
+	if s.seed != 0 {
+		rand.New(rand.NewSource(s.seed)).Shuffle(len(shards), func(i, j int) {
+			shards[i], shards[j] = shards[j], shards[i]
+		})
+	}
+	if opts.Resume != nil {
+		for i := range s.finished {
+			if s.finished[i] {
+				s.done.Add(int64(util.Pow26(len(orders[shards[i].order]) - 1)))
+			}
+		}
+	}
+	resumed := s.done.Load()
+	out := make(chan Result, opts.Top)
+
+	left := []int{}
+	for i := range shards {
+		if !s.finished[i] {
+			left = append(left, i)
+		}
+	}
+	go func() {
+		util.Work(ctx, left, opts.Workers, s.run)
+		close(s.found)
+	}()
+
+	go func() {
+		defer close(out)
+		start := time.Now()
+		report := func() {
+			if opts.Progress != nil {
+				opts.Progress(progress(s.done.Load(), resumed, total, time.Since(start)))
+			}
+		}
+		progressTicker := time.NewTicker(opts.ProgressInterval)
+		defer progressTicker.Stop()
+		var checkpoints <-chan time.Time
+		if opts.Checkpoint != nil {
+			checkpointTicker := time.NewTicker(opts.CheckpointInterval)
+			defer checkpointTicker.Stop()
+			checkpoints = checkpointTicker.C
+		}
+
+		best := ranking{top: opts.Top}
+		send := func(r Result) {
+			if rank, ok := best.add(r); ok {
+				r.Rank = rank
+				out <- r
+			}
+		}
+		for _, r := range s.kept.results {
+			send(r)
+		}
+		for {
+			select {
+			case f, ok := <-s.found:
+				if !ok {
+					report()
+					if opts.Checkpoint != nil {
+						opts.Checkpoint(s.checkpoint())
+					}
+					return
+				}
+				for _, r := range f.results {
+					send(r)
+				}
+				if f.complete {
+					for _, r := range f.results {
+						s.kept.add(r)
+					}
+					s.finished[f.shard] = true
+				}
+			case <-progressTicker.C:
+				report()
+			case <-checkpoints:
+				opts.Checkpoint(s.checkpoint())
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// sweep is the state shared by the workers of a search.
+type sweep struct {
+	ctx      context.Context
+	opts     Options
+	orders   [][]string
+	machines []*enigma.EnigmaMachine
+	shards   []shard
+	done     atomic.Int64
+	found    chan found
+
+	// the state for checkpoints, kept by the goroutine collecting results
+	keyspace string
+	seed     int64
+	finished []bool
+	kept     ranking
+}
+
+// found is the best results of a shard, which is complete unless the sweep
+// was stopped part way through it.
+type found struct {
+	shard    int
+	results  []Result
+	complete bool
+}
+
+// run tries every start position of a shard and sends its best results.
+func (s *sweep) run(i int) {
+	j := s.shards[i]
+	order := s.orders[j.order]
+	em := s.machines[j.order].Clone()
+	em.SetRotorRingSettings(strings.Split(j.rings, ""))
+
+	positions := make([]string, len(order))
+	positions[0] = util.Alphabet[j.left]
+	best := ranking{top: s.opts.Top}
+	complete := true
+	for index := 0; index < util.Pow26(len(order)-1); index++ {
+		if s.ctx.Err() != nil {
+			complete = false
+			break
+		}
+		for i, n := len(order)-1, index; i > 0; i, n = i-1, n/enigma.ALPHABET_SIZE {
+			positions[i] = util.Alphabet[n%enigma.ALPHABET_SIZE]
+		}
+		em.SetRotorPositions(positions)
+
+		score := s.opts.Score(em)
+		s.done.Add(1)
+		if !best.qualifies(score) {
+			continue
+		}
+		config := s.opts.Machine
+		config.Rotors = order
+		config.RotorPositions = strings.Join(positions, "")
+		config.RotorRingSettings = j.rings
+		best.add(Result{Config: config, Score: score})
+	}
+	s.found <- found{shard: i, results: best.results, complete: complete}
+}
+
+// resume marks the shards done in a checkpoint and takes its results.
+func (s *sweep) resume(c *Checkpoint) error {
+	if c.Keyspace != s.keyspace {
+		return fmt.Errorf("the checkpoint is for a different search")
+	}
+	if c.Cursor < 0 || c.Cursor > len(s.shards) {
+		return fmt.Errorf("invalid checkpoint cursor: %d", c.Cursor)
+	}
+	s.seed = c.Seed
+	for i := 0; i < c.Cursor; i++ {
+		s.finished[i] = true
+	}
+	for _, i := range c.Done {
+		if i < 0 || i >= len(s.shards) {
+			return fmt.Errorf("invalid checkpoint shard: %d", i)
+		}
+		s.finished[i] = true
+	}
+	for _, r := range c.Best {
+		s.kept.add(r)
+	}
+	return nil
+}
+
+// checkpoint returns the state of the sweep.
+func (s *sweep) checkpoint() Checkpoint {
+	c := Checkpoint{Keyspace: s.keyspace, Seed: s.seed, Best: make([]Result, len(s.kept.results))}
+	for c.Cursor < len(s.finished) && s.finished[c.Cursor] {
+		c.Cursor++
+	}
+	for i := c.Cursor + 1; i < len(s.finished); i++ {
+		if s.finished[i] {
+			c.Done = append(c.Done, i)
+		}
+	}
+	for i, r := range s.kept.results {
+		r.Rank = i + 1
+		c.Best[i] = r
+	}
+	return c
+}
+
+// keyspace returns a digest of the options that decide the shards and
+// results of a sweep.
+func keyspace(machine enigma.Config, orders [][]string, rings []string, top int) string {
+	data, _ := json.Marshal(struct {
+		Machine enigma.Config
+		Orders  [][]string
+		Rings   []string
+		Top     int
+	}{machine, orders, rings, top})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ranking keeps the best results, best first.
+type ranking struct {
+	top     int
+	results []Result
+}
+
+// qualifies reports whether a score could make it into the ranking, before
+// ties are broken.
+func (r *ranking) qualifies(score float64) bool {
+	return len(r.results) < r.top || score >= r.results[len(r.results)-1].Score
+}
+
+// add puts a result into the ranking if it qualifies, and returns its rank.
+func (r *ranking) add(result Result) (int, bool) {
+	if len(r.results) == r.top && !better(result, r.results[len(r.results)-1]) {
+		return 0, false
+	}
+	i := len(r.results)
+	for i > 0 && better(result, r.results[i-1]) {
+		i--
+	}
+	if len(r.results) < r.top {
+		r.results = append(r.results, Result{})
+	}
+	copy(r.results[i+1:], r.results[i:])
+	r.results[i] = result
+	return i + 1, true
+}
+
+// better orders results by score, and equal scores by their settings, so the
+// ranking does not depend on the order the workers finish in.
+func better(a, b Result) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return settingKey(a.Config) < settingKey(b.Config)
+}
+
+func settingKey(c enigma.Config) string {
+	return strings.Join(c.Rotors, " ") + "/" + c.RotorRingSettings + "/" + c.RotorPositions
+}
+
+// Collect reads results until the channel is closed and returns the best n,
+// best first, ranked again.
+func Collect(results <-chan Result, n int) []Result {
+	best := ranking{top: n}
+	for r := range results {
+		best.add(r)
+	}
+	for i := range best.results {
+		best.results[i].Rank = i + 1
+	}
+	return best.results
+}
+
+// DecryptScore returns a ScoreFunc that decrypts the ciphertext from each
+// trial's start position and rates the decrypt with the scorer. The
+// ciphertext is normalized as the machine does, so it accepts exactly the
+// ciphertexts the machine can decrypt.
+func DecryptScore(ciphertext string, scorer scoring.Scorer) (ScoreFunc, error) {
+	normalized, err := enigma.Normalize(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("the ciphertext has no letters")
+	}
+	cipher := []byte(normalized)
+	for i := range cipher {
+		cipher[i] -= 'A'
+	}
+
+	return func(em *enigma.EnigmaMachine) float64 {
+		text := make([]byte, len(cipher))
+		copy(text, cipher)
+		if err := em.EncryptIndices(text); err != nil {
+			return 0
+		}
+		for i := range text {
+			text[i] += 'A'
+		}
+		return scorer.Score(text)
+	}, nil
+}
+
+// progress estimates the time left from the trials done since the sweep was
+// started or resumed.
+func progress(done, resumed, total int64, elapsed time.Duration) Progress {
+	p := Progress{Done: done, Total: total, Elapsed: elapsed}
+	if done > resumed {
+		p.Remaining = time.Duration(float64(elapsed) * float64(total-done) / float64(done-resumed))
+	}
+	return p
+}
@@ -0,0 +1,246 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+	"github.com/natac13/go-enigma-machine/pkg/scoring"
+)
+
+const searchPlaintext = "DIEVORHUTDESREGIMENTSHATHEUTEMORGENDENFLUSSERREICHTUNDDIEBRUECKEUNBESCHAEDIGTINBESITZGENOMMENXDERFEINDHATSICHINDIEWAELDERNOERDLICHDERSTADTZURUECKGEZOGEN"
+
+func TestSearch(t *testing.T) {
+	key := enigma.Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorPositions:    "BLE",
+		RotorRingSettings: "AAN",
+		Reflector:         enigma.ReflectorConfig{Name: "B"},
+		Plugboard:         enigma.PlugboardConfig{Pairs: []string{"AV", "BS", "CG"}},
+	}
+	em, err := key.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := em.EncryptString(searchPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	score, err := DecryptScore(ciphertext, scoring.IoC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last Progress
+	results, err := Search(context.Background(), Options{
+		Machine:      enigma.Config{Plugboard: key.Plugboard},
+		RotorOrders:  [][]string{{"IV", "II", "V"}, {"II", "IV", "V"}},
+		RingSettings: []string{"AAA", "AAN"},
+		Score:        score,
+		Top:          3,
+		Workers:      2,
+		Progress:     func(p Progress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := 0
+	best := []Result{}
+	for r := range results {
+		sent++
+		if r.Rank < 1 || r.Rank > 3 {
+			t.Errorf("result ranked %d, want 1 to 3", r.Rank)
+		}
+		best = append(best, r)
+	}
+	best = Collect(sliceChannel(best), 3)
+	if sent < 3 || len(best) != 3 {
+		t.Fatalf("got %d results streamed and %d collected, want at least 3 and 3", sent, len(best))
+	}
+
+	got := best[0].Config
+	if strings.Join(got.Rotors, " ") != "II IV V" || got.RotorPositions != "BLE" || got.RotorRingSettings != "AAN" {
+		t.Errorf("best result = %v %s ring %s, want II IV V BLE ring AAN", got.Rotors, got.RotorPositions, got.RotorRingSettings)
+	}
+	if got.Reflector.Name != "B" || len(got.Plugboard.Pairs) != 3 {
+		t.Errorf("best result reflector %s plugs %v, want B and the shared plugboard", got.Reflector.Name, got.Plugboard.Pairs)
+	}
+	for i := 1; i < len(best); i++ {
+		if best[i].Score > best[i-1].Score || best[i].Rank != i+1 {
+			t.Errorf("results not ranked best first: %v", best)
+		}
+	}
+
+	if last.Done != 4*17576 || last.Total != 4*17576 || last.Fraction() != 1 {
+		t.Errorf("final progress = %d of %d, want %d of %d", last.Done, last.Total, 4*17576, 4*17576)
+	}
+}
+
+func TestSearch_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int64
+	var last Progress
+	results, err := Search(ctx, Options{
+		Score: func(em *enigma.EnigmaMachine) float64 {
+			if calls.Add(1) == 1000 {
+				cancel()
+			}
+			return float64(em.GetRotorPositions()[2])
+		},
+		Workers:  2,
+		Progress: func(p Progress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best := Collect(results, 10)
+	if len(best) == 0 || best[0].Score != 25 {
+		t.Errorf("expected the results found before cancelling, got %v", best)
+	}
+	if last.Total != 60*17576 || last.Done >= last.Total || last.Done < 1000 {
+		t.Errorf("final progress = %d of %d, want the sweep stopped early", last.Done, last.Total)
+	}
+	if last.Remaining <= 0 {
+		t.Errorf("Remaining = %s, want an estimate", last.Remaining)
+	}
+}
+
+func TestSearch_Resume(t *testing.T) {
+	em, err := enigma.Config{
+		Rotors:            []string{"II", "IV", "V"},
+		RotorPositions:    "BLE",
+		RotorRingSettings: "AAN",
+		Reflector:         enigma.ReflectorConfig{Name: "B"},
+	}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := em.EncryptString(searchPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	score, err := DecryptScore(ciphertext, scoring.IoC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int64
+	counted := func(em *enigma.EnigmaMachine) float64 {
+		calls.Add(1)
+		return score(em)
+	}
+	opts := Options{
+		RotorOrders:  [][]string{{"II", "IV", "V"}},
+		RingSettings: []string{"AAA", "AAN"},
+		Score:        counted,
+		Top:          5,
+		Workers:      2,
+		Seed:         7,
+	}
+	run := func(ctx context.Context, opts Options) []Result {
+		t.Helper()
+		results, err := Search(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Collect(results, opts.Top)
+	}
+
+	want := run(context.Background(), opts)
+	unseeded := opts
+	unseeded.Seed = 0
+	if got := run(context.Background(), unseeded); !reflect.DeepEqual(got, want) {
+		t.Errorf("results depend on the seed:\n%v\n%v", got, want)
+	}
+
+	// stop part way through and keep the last checkpoint
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls.Store(0)
+	var checkpoint Checkpoint
+	stopped := opts
+	stopped.Score = func(em *enigma.EnigmaMachine) float64 {
+		if calls.Load() == 12000 {
+			cancel()
+		}
+		return counted(em)
+	}
+	stopped.Checkpoint = func(c Checkpoint) { checkpoint = c }
+	stopped.CheckpointInterval = time.Millisecond
+	run(ctx, stopped)
+
+	if checkpoint.Seed != 7 || checkpoint.Cursor+len(checkpoint.Done) == 0 || checkpoint.Cursor == 52 {
+		t.Fatalf("checkpoint = seed %d cursor %d done %v, want part of the sweep", checkpoint.Seed, checkpoint.Cursor, checkpoint.Done)
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resume := &Checkpoint{}
+	if err := json.Unmarshal(data, resume); err != nil {
+		t.Fatal(err)
+	}
+
+	calls.Store(0)
+	resumed := opts
+	resumed.Seed = 0
+	resumed.Resume = resume
+	if got := run(context.Background(), resumed); !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed results = %v, want %v", got, want)
+	}
+	finished := int64(checkpoint.Cursor+len(checkpoint.Done)) * 676
+	if got := calls.Load(); got != 2*17576-finished {
+		t.Errorf("resumed sweep tried %d settings, want %d", got, 2*17576-finished)
+	}
+
+	other := opts
+	other.RingSettings = []string{"AAA"}
+	other.Resume = resume
+	if _, err := Search(context.Background(), other); err == nil {
+		t.Error("expected an error resuming with different options")
+	}
+}
+
+func TestSearch_Invalid(t *testing.T) {
+	score := func(em *enigma.EnigmaMachine) float64 { return 0 }
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no score", Options{}},
+		{"unknown rotor", Options{RotorOrders: [][]string{{"I", "II", "IX"}}, Score: score}},
+		{"ring settings", Options{RingSettings: []string{"AA"}, Score: score}},
+		{"too few rotors", Options{Rotors: []string{"I", "II"}, Score: score}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Search(context.Background(), tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	for _, ciphertext := range []string{"", " \n", "ABC-DEF", "12 34"} {
+		if _, err := DecryptScore(ciphertext, scoring.IoC); err == nil {
+			t.Errorf("DecryptScore(%q) expected an error", ciphertext)
+		}
+	}
+}
+
+func sliceChannel(results []Result) <-chan Result {
+	c := make(chan Result, len(results))
+	for _, r := range results {
+		c <- r
+	}
+	close(c)
+	return c
+}
@@ -0,0 +1,35 @@
+package zygalski
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+)
+
+// Checkpoint is the state of a match over many rotor orders, written out so a
+// long match can be stopped and resumed.
+type Checkpoint struct {
+	// Keyspace identifies the indicators and options the match was started
+	// with.
+	Keyspace string `json:"keyspace"`
+	// Rings holds the surviving ring settings of each rotor order matched,
+	// by the index of the order.
+	Rings map[int][]string `json:"rings"`
+}
+
+func (c Checkpoint) clone() Checkpoint {
+	return Checkpoint{Keyspace: c.Keyspace, Rings: maps.Clone(c.Rings)}
+}
+
+// keyspace returns a digest of the indicators and the options that decide
+// the settings of a match.
+func keyspace(indicators []Indicator, orders [][]string, reflector string) string {
+	data, _ := json.Marshal(struct {
+		Indicators []Indicator
+		Orders     [][]string
+		Reflector  string
+	}{indicators, orders, reflector})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,244 @@
+package zygalski
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// Indicator is the start of a message: the start position sent in the clear
+// and the doubled message key encrypted at it.
+type Indicator struct {
+	Position string
+	Key      string
+}
+
+// ParseIndicator reads an indicator written as the clear start position and
+// the six encrypted letters, such as "RTJ WAHWIK". Spaces are ignored.
+func ParseIndicator(s string) (Indicator, error) {
+	letters := strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	if len(letters) != 9 {
+		return Indicator{}, fmt.Errorf("indicator %q must have a 3 letter position and 6 letters", s)
+	}
+	for _, r := range letters {
+		if r < 'A' || r > 'Z' {
+			return Indicator{}, fmt.Errorf("invalid letter: %c", r)
+		}
+	}
+	return Indicator{Position: letters[:3], Key: letters[3:]}, nil
+}
+
+func (i Indicator) String() string {
+	return fmt.Sprintf("%s %s", i.Position, i.Key)
+}
+
+// Females returns the pairs of letters that repeat in the encrypted key: 0
+// when the first and fourth letters are the same, 1 for the second and
+// fifth and 2 for the third and sixth.
+func (i Indicator) Females() []int {
+	females := []int{}
+	for pair := 0; pair < 3; pair++ {
+		if i.Key[pair] == i.Key[pair+3] {
+			females = append(females, pair)
+		}
+	}
+	return females
+}
+
+// Match stacks the sheets for the females among the indicators and returns
+// the ring settings, as three letters, where every female is possible. The
+// daily key is the rotor order with one of those ring settings. A female in
+// the second or third pair of letters is one in the first pair from a
+// position one or two steps on. Females whose indicator turns the middle
+// rotor are left out, and the number used is returned.
+func (s *Sheets) Match(indicators []Indicator) ([]string, int, error) {
+	em, err := enigma.Config{
+		Rotors:    s.Rotors,
+		Reflector: enigma.ReflectorConfig{Name: s.Reflector},
+	}.Build()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the start positions of the females, shifted to the first pair
+	positions := [][3]int{}
+	for _, indicator := range indicators {
+		p, err := parsePosition(indicator.Position)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, pair := range indicator.Females() {
+			turn, err := turns(em, p)
+			if err != nil {
+				return nil, 0, err
+			}
+			if turn {
+				continue
+			}
+			positions = append(positions, [3]int{p[0], p[1], (p[2] + pair) % 26})
+		}
+	}
+	if len(positions) == 0 {
+		return nil, 0, fmt.Errorf("no usable females among the indicators")
+	}
+
+	// the rotors are at each start position less the rings, so every ring
+	// setting lines the sheets up differently
+	rings := []string{}
+	for index := 0; index < 26*26*26; index++ {
+		ring := [3]int{index / 676, index / 26 % 26, index % 26}
+		match := true
+		for _, p := range positions {
+			core := (p[0]-ring[0]+26)%26*676 + (p[1]-ring[1]+26)%26*26 + (p[2]-ring[2]+26)%26
+			if !s.females[core] {
+				match = false
+				break
+			}
+		}
+		if match {
+			rings = append(rings, strings.Join(util.Letters(ring[:]), ""))
+		}
+	}
+	return rings, len(positions), nil
+}
+
+// Options chooses the rotor orders to match against.
+type Options struct {
+	// RotorOrders are the rotor orders to try, left to right. When empty,
+	// every order of three of Rotors is tried.
+	RotorOrders [][]string
+	// Rotors to build the orders from, defaults to I to V.
+	Rotors []string
+	// Reflector defaults to B.
+	Reflector string
+	// Workers is the number of rotor orders worked on at once, defaults to
+	// the number of CPUs.
+	Workers int
+	// Checkpoint, when set, is called with the rotor orders matched so far
+	// every CheckpointInterval, and once more when the match ends.
+	Checkpoint func(Checkpoint)
+	// CheckpointInterval defaults to one minute.
+	CheckpointInterval time.Duration
+	// Resume continues a match from a checkpoint taken with the same
+	// indicators and options, skipping the rotor orders it has.
+	Resume *Checkpoint
+}
+
+// Setting is a rotor order and ring settings that survived the sheets.
+type Setting struct {
+	Rotors       []string
+	RingSettings string
+}
+
+func (s Setting) String() string {
+	return fmt.Sprintf("%s ring %s", strings.Join(s.Rotors, " "), s.RingSettings)
+}
+
+// Match builds the sheets of every rotor order and stacks them for the
+// indicators, returning the surviving settings by rotor order.
+func Match(indicators []Indicator, opts Options) ([]Setting, error) {
+	orders := opts.RotorOrders
+	if len(orders) == 0 {
+		rotors := opts.Rotors
+		if len(rotors) == 0 {
+			rotors = []string{"I", "II", "III", "IV", "V"}
+		}
+		orders = enigma.RotorOrders(rotors, 3)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	if opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = time.Minute
+	}
+
+	checkpoint := Checkpoint{
+		Keyspace: keyspace(indicators, orders, opts.Reflector),
+		Rings:    map[int][]string{},
+	}
+	if r := opts.Resume; r != nil {
+		if r.Keyspace != checkpoint.Keyspace {
+			return nil, fmt.Errorf("the checkpoint is for different indicators or options")
+		}
+		for i, rings := range r.Rings {
+			if i < 0 || i >= len(orders) {
+				return nil, fmt.Errorf("invalid checkpoint rotor order: %d", i)
+			}
+			checkpoint.Rings[i] = rings
+		}
+	}
+
+	// the rings of each order are collected here, so the checkpoint is kept
+	// and saved by this goroutine alone
+	type matched struct {
+		order int
+		rings []string
+		err   error
+	}
+	left := []int{}
+	for i := range orders {
+		if _, ok := checkpoint.Rings[i]; !ok {
+			left = append(left, i)
+		}
+	}
+	found := make(chan matched)
+	go func() {
+		util.Work(context.Background(), left, workers, func(i int) {
+			s, err := NewSheets(orders[i], opts.Reflector)
+			if err != nil {
+				found <- matched{order: i, err: err}
+				return
+			}
+			rings, _, err := s.Match(indicators)
+			found <- matched{order: i, rings: rings, err: err}
+		})
+		close(found)
+	}()
+
+	var checkpoints <-chan time.Time
+	if opts.Checkpoint != nil {
+		ticker := time.NewTicker(opts.CheckpointInterval)
+		defer ticker.Stop()
+		checkpoints = ticker.C
+	}
+	var err error
+	for done := false; !done; {
+		select {
+		case m, ok := <-found:
+			if !ok {
+				done = true
+				break
+			}
+			if m.err != nil {
+				if err == nil {
+					err = m.err
+				}
+				continue
+			}
+			checkpoint.Rings[m.order] = m.rings
+		case <-checkpoints:
+			opts.Checkpoint(checkpoint.clone())
+		}
+	}
+	if opts.Checkpoint != nil {
+		opts.Checkpoint(checkpoint.clone())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []Setting{}
+	for i, order := range orders {
+		for _, rings := range checkpoint.Rings[i] {
+			settings = append(settings, Setting{Rotors: order, RingSettings: rings})
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,183 @@
+package zygalski
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// dailyTraffic returns the indicators of a day's messages, each with its own
+// start position and message key.
+func dailyTraffic(t *testing.T, key enigma.Config, messages int) []Indicator {
+	em, err := key.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	random := rand.New(rand.NewSource(1938))
+	randomLetters := func(n int) string {
+		l := make([]byte, n)
+		for i := range l {
+			l[i] = byte('A' + random.Intn(26))
+		}
+		return string(l)
+	}
+
+	indicators := []Indicator{}
+	for i := 0; i < messages; i++ {
+		position, messageKey := randomLetters(3), randomLetters(3)
+		if err := em.SetRotorPositions([]string{position[:1], position[1:2], position[2:]}); err != nil {
+			t.Fatal(err)
+		}
+		encrypted, err := em.DecryptString(messageKey + messageKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		indicators = append(indicators, Indicator{Position: position, Key: encrypted})
+	}
+	return indicators
+}
+
+func TestMatch(t *testing.T) {
+	key := enigma.Config{
+		Rotors:            []string{"II", "I", "III"},
+		RotorRingSettings: "KQF",
+		Reflector:         enigma.ReflectorConfig{Name: "B"},
+		Plugboard:         enigma.PlugboardConfig{Pairs: []string{"AV", "BS", "CG", "DL", "FU", "HZ"}},
+	}
+	indicators := dailyTraffic(t, key, 300)
+
+	s, err := NewSheets(key.Rotors, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rings, used, err := s.Match(indicators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used < 10 {
+		t.Fatalf("expected at least 10 usable females, got %d", used)
+	}
+	if !slices.Contains(rings, key.RotorRingSettings) {
+		t.Fatalf("expected ring settings %s to survive, got %v", key.RotorRingSettings, rings)
+	}
+	if len(rings) > 5 {
+		t.Errorf("expected the sheets to leave only a few settings, got %d", len(rings))
+	}
+
+	settings, err := Match(indicators, Options{RotorOrders: [][]string{{"I", "II", "III"}, key.Rotors}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(settings, func(s Setting) bool {
+		return slices.Equal(s.Rotors, key.Rotors) && s.RingSettings == key.RotorRingSettings
+	}) {
+		t.Fatalf("expected %v ring %s among %v", key.Rotors, key.RotorRingSettings, settings)
+	}
+}
+
+func TestMatch_Resume(t *testing.T) {
+	key := enigma.Config{
+		Rotors:            []string{"II", "I", "III"},
+		RotorRingSettings: "KQF",
+		Reflector:         enigma.ReflectorConfig{Name: "B"},
+	}
+	indicators := dailyTraffic(t, key, 300)
+	opts := Options{RotorOrders: [][]string{{"I", "II", "III"}, key.Rotors}, Workers: 1}
+	want, err := Match(indicators, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keep a checkpoint taken after the first order, as if the match had
+	// been stopped there
+	var checkpoint []byte
+	stopped := opts
+	stopped.CheckpointInterval = time.Millisecond
+	stopped.Checkpoint = func(c Checkpoint) {
+		if len(c.Rings) == 1 {
+			data, err := json.Marshal(c)
+			if err != nil {
+				t.Error(err)
+			}
+			checkpoint = data
+		}
+	}
+	if _, err := Match(indicators, stopped); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint == nil {
+		t.Fatal("expected a checkpoint with one rotor order matched")
+	}
+
+	resume := &Checkpoint{}
+	if err := json.Unmarshal(checkpoint, resume); err != nil {
+		t.Fatal(err)
+	}
+	resumed := opts
+	resumed.Resume = resume
+	got, err := Match(indicators, resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed settings = %v, want %v", got, want)
+	}
+
+	other := opts
+	other.Resume = resume
+	if _, err := Match(indicators[1:], other); err == nil {
+		t.Error("expected an error resuming with different indicators")
+	}
+}
+
+func TestMatch_NoFemales(t *testing.T) {
+	s, err := NewSheets([]string{"I", "II", "III"}, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Match([]Indicator{{Position: "ABC", Key: "DEFGHI"}}); err == nil {
+		t.Error("expected error without females, got nil")
+	}
+}
+
+func TestParseIndicator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Indicator
+		females  []int
+		valid    bool
+	}{
+		{"RTJ WAHWIK", Indicator{"RTJ", "WAHWIK"}, []int{0}, true},
+		{"rtjwahwik", Indicator{"RTJ", "WAHWIK"}, []int{0}, true},
+		{"ABC DEFDEF", Indicator{"ABC", "DEFDEF"}, []int{0, 1, 2}, true},
+		{"ABC DEFGHI", Indicator{"ABC", "DEFGHI"}, []int{}, true},
+		{"ABC DEFGH", Indicator{}, nil, false},
+		{"AB1 DEFGHI", Indicator{}, nil, false},
+	}
+
+	for _, test := range tests {
+		i, err := ParseIndicator(test.input)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected error, got nil", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+			continue
+		}
+		if i != test.expected {
+			t.Errorf("%q: expected %v, got %v", test.input, test.expected, i)
+		}
+		if !slices.Equal(i.Females(), test.females) {
+			t.Errorf("%q: expected females %v, got %v", test.input, test.females, i.Females())
+		}
+	}
+}
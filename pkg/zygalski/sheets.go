@@ -0,0 +1,166 @@
+// Package zygalski implements Henryk Zygalski's perforated sheets, used from
+// late 1938 to find Enigma daily keys.
+//
+// Under the procedure of the time the operator picked a start position for
+// each message and sent it in the clear, then his message key typed twice
+// and encrypted at that position. Now and then an indicator repeats a letter
+// three places on, such as the A in ABCADE. Such a female can only happen at
+// scrambler positions where the first and fourth permutations share a fixed
+// point, which is about two positions in five. A sheet marks those positions
+// for one rotor order and left rotor position, and stacking the sheets for a
+// day's females, shifted by their start positions, leaves only the ring
+// settings where every one of them is possible.
+package zygalski
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/natac13/go-enigma-machine/internal/util"
+	"github.com/natac13/go-enigma-machine/pkg/enigma"
+)
+
+// Sheets holds the female positions of one rotor order, the data of its 26
+// sheets.
+type Sheets struct {
+	Rotors    []string
+	Reflector string
+	// females is indexed by the left, middle and right rotor positions with
+	// the rings at A.
+	females []bool
+}
+
+// NewSheets computes the female positions of a rotor order. Like the paper
+// sheets, they are for the rings at A and assume the middle rotor does not
+// turn within the six indicator letters.
+func NewSheets(order []string, reflector string) (*Sheets, error) {
+	if len(order) != 3 {
+		return nil, fmt.Errorf("sheets need 3 rotors, got %d", len(order))
+	}
+	if reflector == "" {
+		reflector = "B"
+	}
+	em, err := enigma.Config{
+		Rotors:    order,
+		Reflector: enigma.ReflectorConfig{Name: reflector},
+	}.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sheets{Rotors: order, Reflector: reflector, females: make([]bool, 26*26*26)}
+	for index := range s.females {
+		position := [3]int{index / 676, index / 26 % 26, index % 26}
+		s.females[index], err = female(em, position)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// female reports whether some letter typed at the first and fourth key press
+// from the position comes out the same, which is what a female needs. If the
+// middle or left rotor would turn, the rings are moved along with the
+// positions until they do not, which keeps the wiring in the same place.
+func female(em *enigma.EnigmaMachine, position [3]int) (bool, error) {
+	for _, shift := range [][2]int{{0, 0}, {0, 7}, {7, 0}, {7, 7}} {
+		rings := []int{0, shift[0], shift[1]}
+		positions := []int{position[0], (position[1] + shift[0]) % 26, (position[2] + shift[1]) % 26}
+		if err := setMachine(em, positions, rings); err != nil {
+			return false, err
+		}
+
+		found := false
+		for x := 0; x < 26; x++ {
+			em.Reset()
+			out, err := em.DecryptString(strings.Repeat(string(rune('A'+x)), 6))
+			if err != nil {
+				return false, err
+			}
+			if out[0] == out[3] {
+				found = true
+			}
+		}
+
+		after := em.GetRotorPositions()
+		if after[0] == positions[0] && after[1] == positions[1] {
+			return found, nil
+		}
+	}
+	return false, fmt.Errorf("could not keep the middle rotor from turning at %v", position)
+}
+
+// turns reports whether the middle or left rotor turns within the six
+// indicator letters from the position, which depends only on the letters in
+// the windows and not on the rings.
+func turns(em *enigma.EnigmaMachine, position [3]int) (bool, error) {
+	positions := position[:]
+	if err := setMachine(em, positions, []int{0, 0, 0}); err != nil {
+		return false, err
+	}
+	if _, err := em.DecryptString("AAAAAA"); err != nil {
+		return false, err
+	}
+	after := em.GetRotorPositions()
+	return after[0] != positions[0] || after[1] != positions[1], nil
+}
+
+func setMachine(em *enigma.EnigmaMachine, positions, rings []int) error {
+	if err := em.SetRotorRingSettings(util.Letters(rings)); err != nil {
+		return err
+	}
+	return em.SetRotorPositions(util.Letters(positions))
+}
+
+// Female reports whether a female is possible at a three letter position,
+// with the rings at A.
+func (s *Sheets) Female(position string) (bool, error) {
+	p, err := parsePosition(position)
+	if err != nil {
+		return false, err
+	}
+	return s.females[p[0]*676+p[1]*26+p[2]], nil
+}
+
+// Sheet returns the sheet for a left rotor position, indexed by the middle
+// and then the right rotor position. True marks a hole.
+func (s *Sheets) Sheet(left rune) ([26][26]bool, error) {
+	sheet := [26][26]bool{}
+	if left < 'A' || left > 'Z' {
+		return sheet, fmt.Errorf("invalid letter: %c", left)
+	}
+	l := int(left - 'A')
+	for m := 0; m < 26; m++ {
+		for r := 0; r < 26; r++ {
+			sheet[m][r] = s.females[l*676+m*26+r]
+		}
+	}
+	return sheet, nil
+}
+
+// Holes returns the share of positions where a female is possible.
+func (s *Sheets) Holes() float64 {
+	holes := 0
+	for _, f := range s.females {
+		if f {
+			holes++
+		}
+	}
+	return float64(holes) / float64(len(s.females))
+}
+
+func parsePosition(position string) ([3]int, error) {
+	p := [3]int{}
+	position = strings.ToUpper(position)
+	if len(position) != 3 {
+		return p, fmt.Errorf("position %s must be 3 letters long", position)
+	}
+	for i := range p {
+		if position[i] < 'A' || position[i] > 'Z' {
+			return p, fmt.Errorf("invalid letter: %c", position[i])
+		}
+		p[i] = int(position[i] - 'A')
+	}
+	return p, nil
+}
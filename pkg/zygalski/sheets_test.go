@@ -0,0 +1,93 @@
+package zygalski
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSheets(t *testing.T) {
+	s, err := NewSheets([]string{"II", "I", "III"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Reflector != "B" {
+		t.Errorf("expected reflector B by default, got %s", s.Reflector)
+	}
+
+	// about two positions in five allow a female
+	if holes := s.Holes(); holes < 0.3 || holes > 0.5 {
+		t.Errorf("expected around 40%% holes, got %.1f%%", holes*100)
+	}
+
+	sheet, err := s.Sheet('K')
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range []string{"KAA", "KDC", "KZZ", "KMQ"} {
+		f, err := s.Female(position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sheet[position[1]-'A'][position[2]-'A'] != f {
+			t.Errorf("%s: the sheet does not match Female", position)
+		}
+	}
+}
+
+func TestSheets_Invalid(t *testing.T) {
+	if _, err := NewSheets([]string{"I", "II"}, "B"); err == nil {
+		t.Error("expected error for 2 rotors, got nil")
+	}
+	if _, err := NewSheets([]string{"I", "II", "IX"}, "B"); err == nil {
+		t.Error("expected error for an unknown rotor, got nil")
+	}
+
+	s, err := NewSheets([]string{"I", "II", "III"}, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range []string{"AB", "ABCD", "A1C"} {
+		if _, err := s.Female(position); err == nil {
+			t.Errorf("%s: expected error, got nil", position)
+		}
+	}
+	if _, err := s.Sheet('1'); err == nil {
+		t.Error("expected error for an invalid left rotor position, got nil")
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	s, err := NewSheets([]string{"I", "II", "III"}, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet, err := s.Sheet('A')
+	if err != nil {
+		t.Fatal(err)
+	}
+	holes := 0
+	for m := 0; m < svgSquares; m++ {
+		for r := 0; r < svgSquares; r++ {
+			if sheet[m%26][r%26] {
+				holes++
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteSVG(&buf, 'A'); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatal("expected an svg document")
+	}
+	if !strings.Contains(svg, "I II III B, left rotor A") {
+		t.Error("expected the sheet to be titled")
+	}
+	// one rect per hole, plus the border
+	if got := strings.Count(svg, "<rect"); got != holes+1 {
+		t.Errorf("expected %d rects, got %d", holes+1, got)
+	}
+}
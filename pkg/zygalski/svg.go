@@ -0,0 +1,57 @@
+package zygalski
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// the size of a square on the sheet, in millimetres
+	svgCell = 4
+	// room for the title and the letters along the edges
+	svgMargin = 12
+	// the sheets repeat the positions, A to Z and A to Y again, so that they
+	// can be slid over each other by up to 25 places
+	svgSquares = 51
+)
+
+// WriteSVG draws the sheet for a left rotor position as a printable SVG, with
+// the middle rotor positions down the side and the right rotor positions
+// along the top. Holes are the filled squares, to be cut out.
+func (s *Sheets) WriteSVG(w io.Writer, left rune) error {
+	sheet, err := s.Sheet(left)
+	if err != nil {
+		return err
+	}
+
+	size := svgMargin*2 + svgSquares*svgCell
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%dmm" height="%dmm" viewBox="0 0 %d %d" font-family="monospace" font-size="3">`+"\n",
+		size, size, size, size)
+	fmt.Fprintf(b, `<text x="%d" y="5">%s %s, left rotor %c</text>`+"\n",
+		svgMargin, strings.Join(s.Rotors, " "), s.Reflector, left)
+
+	for i := 0; i < svgSquares; i++ {
+		letter := rune('A' + i%26)
+		offset := svgMargin + i*svgCell
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">%c</text>`+"\n", offset+svgCell/2, svgMargin-1, letter)
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%c</text>`+"\n", svgMargin-1, offset+svgCell-1, letter)
+	}
+
+	fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="black" stroke-width="0.3"/>`+"\n",
+		svgMargin, svgMargin, svgSquares*svgCell, svgSquares*svgCell)
+	for m := 0; m < svgSquares; m++ {
+		for r := 0; r < svgSquares; r++ {
+			if !sheet[m%26][r%26] {
+				continue
+			}
+			fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d"/>`+"\n",
+				svgMargin+r*svgCell+1, svgMargin+m*svgCell+1, svgCell-2, svgCell-2)
+		}
+	}
+
+	fmt.Fprintln(b, `</svg>`)
+	return b.Flush()
+}
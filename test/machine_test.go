@@ -58,6 +58,157 @@ func TestEnigmaMachine_EncryptString_WithRingSetting(t *testing.T) {
 	}
 }
 
+// Operation Barbarossa, 1941. The message is long enough for the middle rotor
+// to double step, so it only decrypts with faithful stepping.
+func TestEnigmaMachine_EncryptString_Barbarossa(t *testing.T) {
+	plugboard := enigma.NewPlugboard()
+	reflector, err := enigma.CreateReflectorB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotors := make([]*enigma.Rotor, 3)
+	for i, name := range []string{"II", "IV", "V"} {
+		rotors[i], err = enigma.CreateRotorFromSelection(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	em := enigma.NewEnigmaMachine(plugboard, rotors, reflector)
+	if err := em.SetRotorRingSettings([]string{"B", "U", "L"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range []string{"AV", "BS", "CG", "DL", "FU", "HZ", "IN", "KM", "OW", "RX"} {
+		if err := em.AddPlugboardConnection(rune(pair[0]), rune(pair[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the message key KCH was sent enciphered at the ground setting WXC
+	if err := em.SetRotorPositions([]string{"W", "X", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	messageKey, err := em.EncryptString("KCH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messageKey != "BLA" {
+		t.Fatalf("expected message key BLA, got %s", messageKey)
+	}
+
+	if err := em.SetRotorPositions([]string{"B", "L", "A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := "EDPUD NRGYS ZRCXN UYTPO MRMBO FKTBZ REZKM LXLVE FGUEY SIOZV EQMIK UBPMM YLKLT TDEIS " +
+		"MDICA GYKUA CTCDO MOHWX MUUIA UBSTS LRNBZ SZWNR FXWFY SSXJZ VIJHI DISHP RKLKA YUPAD " +
+		"TXQSP INQMA TLPIF SVKDA SCTAC DPBOP VHJK"
+	expected := "AUFKL XABTE ILUNG XVONX KURTI NOWAX KURTI NOWAX NORDW ESTLX SEBEZ XSEBE ZXUAF FLIEG " +
+		"ERSTR ASZER IQTUN GXDUB ROWKI XDUBR OWKIX OPOTS CHKAX OPOTS CHKAX UMXEI NSAQT DREIN " +
+		"ULLXU HRANG ETRET ENXAN GRIFF XINFX RGTX"
+
+	decrypted, err := em.EncryptString(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != expected {
+		t.Fatalf("expected %s, got %s", expected, decrypted)
+	}
+}
+
+// With the thin rotor at A and ring setting A, the M4 with a thin reflector is
+// wired to behave exactly like the M3 with the matching thick reflector. This
+// is how the M4 stayed compatible with the M3 keys of shore stations.
+func TestEnigmaMachine_EncryptString_M4CompatibleWithM3(t *testing.T) {
+	tests := []struct {
+		greek         string
+		thinReflector string
+		reflector     string
+	}{
+		{"Beta", "B-thin", "B"},
+		{"Gamma", "C-thin", "C"},
+	}
+
+	message := "DASOBERKOMMANDODERWEHRMACHTGIBTBEKANNTXX"
+
+	for _, test := range tests {
+		m3, err := buildMachine([]string{"VI", "II", "VIII"}, test.reflector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m3.SetRotorPositions([]string{"Q", "E", "Y"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := m3.SetRotorRingSettings([]string{"C", "K", "T"}); err != nil {
+			t.Fatal(err)
+		}
+
+		m4, err := buildMachine([]string{test.greek, "VI", "II", "VIII"}, test.thinReflector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m4.SetRotorPositions([]string{"A", "Q", "E", "Y"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := m4.SetRotorRingSettings([]string{"A", "C", "K", "T"}); err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := m3.EncryptString(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted, err := m4.EncryptString(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if encrypted != expected {
+			t.Fatalf("%s with %s: expected %s, got %s", test.greek, test.thinReflector, expected, encrypted)
+		}
+	}
+}
+
+func TestEnigmaMachine_EncryptString_M4ThinRotorMatters(t *testing.T) {
+	m4, err := buildMachine([]string{"Beta", "II", "IV", "I"}, "B-thin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := "WETTERVORHERSAGEBISKAYA"
+	reference, err := m4.EncryptString(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m4.SetRotorPositions([]string{"D", "A", "A", "A"}); err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := m4.EncryptString(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encrypted == reference {
+		t.Fatalf("expected the thin rotor position to change the ciphertext, got %s both times", encrypted)
+	}
+}
+
+func buildMachine(rotorSelection []string, reflectorSelection string) (*enigma.EnigmaMachine, error) {
+	reflector, err := enigma.CreateReflectorFromSelection(reflectorSelection)
+	if err != nil {
+		return nil, err
+	}
+	rotors := make([]*enigma.Rotor, len(rotorSelection))
+	for i, name := range rotorSelection {
+		rotors[i], err = enigma.CreateRotorFromSelection(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return enigma.NewEnigmaMachine(enigma.NewPlugboard(), rotors, reflector), nil
+}
+
 func setupEnigmaMachine() (*enigma.EnigmaMachine, error) {
 	plugboard := enigma.NewPlugboard()
 	reflector, err := enigma.CreateReflectorB()